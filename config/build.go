@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/MrM21632/snowball/lease"
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Builds the server ID provider for the configured sources, tried in order. The configuration must be valid.
+func (c *Config) ServerIDProvider() (snowball.ServerIDProvider, error) {
+	mapping := snowball.IPMapping{Bits: uint8(c.IP.Bits)}
+	if _, network, err := parseCIDR(c.IP.CIDR); err != nil {
+		return nil, fmt.Errorf("ip.cidr: %w", err)
+	} else {
+		mapping.Network = network
+	}
+
+	var providers []snowball.ServerIDProvider
+	for _, name := range c.ServerIdSources {
+		switch name {
+		case "env":
+			providers = append(providers, staticProvider{id: c.ServerId})
+		case "ip":
+			providers = append(providers, ipProvider{address: c.IP.Address, mapping: mapping})
+		case "interface":
+			p := &snowball.InterfaceServerIDProvider{Interface: c.Interface.Name, Mapping: mapping}
+			if _, network, err := parseCIDR(c.Interface.CIDR); err != nil {
+				return nil, fmt.Errorf("interface.cidr: %w", err)
+			} else {
+				p.Network = network
+			}
+			providers = append(providers, p)
+		case "ordinal":
+			p := &snowball.OrdinalServerIDProvider{Offset: c.Ordinal.Offset, Range: c.Ordinal.Range}
+			if c.Ordinal.Pattern != "" {
+				pattern, err := regexp.Compile(c.Ordinal.Pattern)
+				if err != nil {
+					return nil, fmt.Errorf("ordinal.pattern: %w", err)
+				}
+				p.Pattern = pattern
+			}
+			providers = append(providers, p)
+		default:
+			return nil, fmt.Errorf("server_id_sources: unknown source %q", name)
+		}
+	}
+	return snowball.FirstOf(providers...), nil
+}
+
+// Builds the allocator for the configured lease backend. Returns nil if leasing is not enabled.
+func (c *Config) Allocator() (lease.ServerIDAllocator, error) {
+	switch {
+	case c.Lease.Dir != "":
+		return lease.NewFileAllocator(c.Lease.Dir)
+	case c.Lease.RedisAddr != "":
+		result := lease.NewRedisAllocator(c.Lease.RedisAddr)
+		result.Password = c.Lease.RedisPassword
+		result.DB = int(c.Lease.RedisDB)
+		result.KeyPrefix = c.Lease.RedisPrefix
+		return result, nil
+	}
+	return nil, nil
+}
+
+// Provides the configured server ID, acting as the "env" source.
+type staticProvider struct {
+	id *uint64
+}
+
+func (p staticProvider) ServerId() (uint64, error) {
+	if p.id == nil {
+		return 0, fmt.Errorf("%w: server_id is not set", snowball.ErrServerIdUnavailable)
+	}
+	return *p.id, nil
+}
+
+func (p staticProvider) String() string {
+	return "env"
+}
+
+// Provides the server ID from the configured IP address, acting as the "ip" source.
+type ipProvider struct {
+	address string
+	mapping snowball.IPMapping
+}
+
+func (p ipProvider) ServerId() (uint64, error) {
+	if p.address == "" {
+		return 0, fmt.Errorf("%w: ip.address is not set", snowball.ErrServerIdUnavailable)
+	}
+	ip := net.ParseIP(p.address)
+	if ip == nil {
+		return 0, fmt.Errorf("get server id failed: invalid IP address %q", p.address)
+	}
+	return snowball.ServerIdFromIP(ip, p.mapping)
+}
+
+func (p ipProvider) String() string {
+	return "ip"
+}
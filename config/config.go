@@ -0,0 +1,254 @@
+// Package config defines the configuration of the Snowball service and loads it from a YAML or TOML file,
+// SNOWBALL_* environment variables and command-line flags.
+//
+// Each setting is taken from the first of these that provides it: command-line flags, then environment
+// variables, then the configuration file, then the built-in default. Load records where each effective value
+// came from, and reports every invalid value at once rather than stopping at the first.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MrM21632/snowball/grpcserver"
+	"github.com/MrM21632/snowball/lease"
+	"github.com/MrM21632/snowball/peers"
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Configuration of the Snowball service.
+type Config struct {
+	// Epoch that ID timestamps are relative to, in milliseconds since the Unix epoch. Files, variables and flags
+	// may also give it as an RFC 3339 timestamp or a date; see snowball.ParseEpoch.
+	EpochMs uint64
+	// Preassigned server ID, used by the "env" server ID source. Nil if not configured.
+	ServerId *uint64
+	// Sources of the server ID, tried in order: "env", "ip", "interface" and "ordinal". Ignored if a lease
+	// backend is configured.
+	ServerIdSources []string
+
+	IP        IPConfig
+	Interface InterfaceConfig
+	Ordinal   OrdinalConfig
+
+	ListenAddr  string
+	MetricsAddr string
+	GRPCAddr    string
+	TLS         TLSConfig
+
+	MaxBatchSize    uint64
+	DrainPeriod     time.Duration
+	ShutdownTimeout time.Duration
+	LogLevel        string
+	// Whether to start each millisecond's sequence at a random number. See snowball.WithRandomSequenceStart.
+	RandomSequence bool
+	// File the time of the last issued ID is persisted in, if any. See snowball.WithStateFile.
+	StateFile string
+	// Whether IDs must fit in a signed 64-bit integer. See snowball.WithSignedSafe.
+	SignedSafe bool
+	// Whether malformed settings are fatal. If false, they are ignored and reported by Warnings.
+	Strict bool
+
+	Lease LeaseConfig
+	Peers PeersConfig
+
+	// Namespaces hosted alongside the default node, by name.
+	Namespaces map[string]*NamespaceConfig
+
+	// Where each setting's value came from, by key.
+	sources map[string]string
+	// Malformed settings ignored because Strict is false.
+	warnings []error
+}
+
+// Configuration of the "ip" server ID source. See snowball.GetServerIdFromIPAddress.
+type IPConfig struct {
+	Address string
+	Bits    uint64
+	CIDR    string
+}
+
+// Configuration of the "interface" server ID source. See snowball.InterfaceServerIDProvider.
+type InterfaceConfig struct {
+	Name string
+	CIDR string
+}
+
+// Configuration of the "ordinal" server ID source. See snowball.OrdinalServerIDProvider.
+type OrdinalConfig struct {
+	Pattern string
+	Offset  uint64
+	Range   uint64
+}
+
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Configuration of server ID leases. Leasing is enabled by setting either Dir or RedisAddr.
+type LeaseConfig struct {
+	Dir           string
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       uint64
+	RedisPrefix   string
+	TTL           time.Duration
+}
+
+// Configuration of server ID collision detection. Detection is enabled by setting URLs.
+type PeersConfig struct {
+	URLs     []string
+	Interval time.Duration
+	// Consecutive rounds a colliding peer may fail to answer before its collision expires. See peers.Detector.
+	MaxMissed uint64
+	// Base URL of this instance, as listed in its peers' URLs. See peers.Detector.
+	SelfURL string
+	// Shared secret peers authenticate announcements with. See peers.Detector.
+	Secret string
+}
+
+// Names of the server ID sources.
+var ServerIdSourceNames = []string{"env", "ip", "interface", "ordinal"}
+
+// Returns the configuration used when nothing is configured.
+func Default() *Config {
+	return &Config{
+		EpochMs:         snowball.DefaultEpochMs,
+		ServerIdSources: []string{"env", "ip", "interface"},
+		ListenAddr:      ":8080",
+		MetricsAddr:     ":9100",
+		GRPCAddr:        ":9090",
+		MaxBatchSize:    grpcserver.DefaultMaxBatchSize,
+		DrainPeriod:     5 * time.Second,
+		ShutdownTimeout: 10 * time.Second,
+		LogLevel:        "info",
+		Strict:          true,
+		Lease:           LeaseConfig{TTL: lease.DefaultTTL},
+		Peers:           PeersConfig{Interval: peers.DefaultInterval, MaxMissed: peers.DefaultMaxMissed},
+	}
+}
+
+// Returns where the value of the setting with the given key came from: "default", "file <path>", "env <NAME>"
+// or "flag -<name>".
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return "default"
+}
+
+// Returns the node's epoch.
+func (c *Config) Epoch() time.Time {
+	return time.UnixMilli(int64(c.EpochMs))
+}
+
+// Returns the layout of the IDs the service generates: snowball.SignedLayout if SignedSafe is set, otherwise
+// snowball.DefaultLayout.
+func (c *Config) Layout() snowball.Layout {
+	if c.SignedSafe {
+		return snowball.SignedLayout
+	}
+	return snowball.DefaultLayout
+}
+
+// Checks the configuration for invalid and inconsistent values, returning all problems found.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s (from %s): %s", key, c.Source(key), fmt.Sprintf(format, args...)))
+	}
+
+	if err := c.Layout().CheckEpoch(c.Epoch(), time.Now()); err != nil {
+		invalid("epoch_ms", "%s", err)
+	}
+	if c.ServerId != nil && *c.ServerId > uint64(snowball.MaxServerId) {
+		invalid("server_id", "must be between 0 and %d", snowball.MaxServerId)
+	}
+	if len(c.ServerIdSources) == 0 {
+		invalid("server_id_sources", "at least one source is required")
+	}
+	for i, name := range c.ServerIdSources {
+		if !slices.Contains(ServerIdSourceNames, name) {
+			invalid("server_id_sources", "unknown source %q, must be one of %s",
+				name, strings.Join(ServerIdSourceNames, ", "))
+		} else if slices.Index(c.ServerIdSources, name) != i {
+			invalid("server_id_sources", "source %q is listed more than once", name)
+		}
+	}
+
+	if c.IP.Address != "" && net.ParseIP(c.IP.Address) == nil {
+		invalid("ip.address", "%q is not a valid IP address", c.IP.Address)
+	}
+	if c.IP.Bits > uint64(snowball.ServerIdLen) {
+		invalid("ip.bits", "must be at most %d", snowball.ServerIdLen)
+	}
+	if _, _, err := parseCIDR(c.IP.CIDR); err != nil {
+		invalid("ip.cidr", "%s", err)
+	}
+	if _, _, err := parseCIDR(c.Interface.CIDR); err != nil {
+		invalid("interface.cidr", "%s", err)
+	}
+	if _, err := regexp.Compile(c.Ordinal.Pattern); err != nil {
+		invalid("ordinal.pattern", "%s", err)
+	}
+	maxServerId := uint64(snowball.MaxServerId)
+	if c.Ordinal.Offset > maxServerId || c.Ordinal.Range > maxServerId+1-c.Ordinal.Offset {
+		invalid("ordinal.range", "block starting at %d with range %d exceeds the maximum server ID %d",
+			c.Ordinal.Offset, c.Ordinal.Range, snowball.MaxServerId)
+	}
+
+	for _, addr := range []struct{ key, value string }{
+		{"listen_addr", c.ListenAddr}, {"metrics_addr", c.MetricsAddr}, {"grpc_addr", c.GRPCAddr},
+	} {
+		if addr.value == "" {
+			invalid(addr.key, "an address is required")
+		}
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		invalid("tls.cert_file", "both a certificate file and a key file must be provided")
+	}
+	if c.MaxBatchSize == 0 {
+		invalid("max_batch_size", "must be at least 1")
+	}
+	if c.DrainPeriod < 0 {
+		invalid("drain_period", "must not be negative")
+	}
+	if c.ShutdownTimeout < 0 {
+		invalid("shutdown_timeout", "must not be negative")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		invalid("log_level", "must be one of debug, info, warn or error")
+	}
+
+	if c.Lease.Dir != "" && c.Lease.RedisAddr != "" {
+		invalid("lease.dir", "lease.dir and lease.redis_addr are exclusive")
+	}
+	if c.Lease.TTL < time.Second {
+		invalid("lease.ttl", "must be at least 1s")
+	}
+	if c.Peers.Interval <= 0 {
+		invalid("peers.interval", "must be positive")
+	}
+	if c.Peers.MaxMissed == 0 {
+		invalid("peers.max_missed", "must be at least 1")
+	}
+	c.validateNamespaces(invalid)
+
+	return errors.Join(errs...)
+}
+
+// Parses a CIDR if it is not empty.
+func parseCIDR(cidr string) (net.IP, *net.IPNet, error) {
+	if cidr == "" {
+		return nil, nil, nil
+	}
+	return net.ParseCIDR(cidr)
+}
@@ -0,0 +1,441 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Clears every variable Load reads, so the tests are not affected by the environment they run in.
+func clearEnv(t *testing.T) {
+	t.Setenv("SNOWBALL_CONFIG", "")
+	for _, s := range settings {
+		if s.env != "" {
+			t.Setenv(s.env, "")
+		}
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func load(args ...string) (*Config, error) {
+	fs := flag.NewFlagSet("snowball", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return Load(fs, args)
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+
+	got, err := load()
+	if err != nil {
+		t.Fatalf("Error occurred running Load: %s", err)
+	}
+	want := Default()
+	if got.ListenAddr != want.ListenAddr || got.EpochMs != want.EpochMs || got.ServerId != nil ||
+		!slices.Equal(got.ServerIdSources, want.ServerIdSources) || got.Lease.TTL != want.Lease.TTL {
+		t.Errorf("Load() = %+v, want defaults %+v", got, want)
+	}
+	if source := got.Source("listen_addr"); source != "default" {
+		t.Errorf("Source(listen_addr) = %q, want default", source)
+	}
+	if got.Layout() != snowball.DefaultLayout {
+		t.Errorf("Layout() = %+v, want: %+v", got.Layout(), snowball.DefaultLayout)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "snowball.yaml", `
+epoch_ms: 1704121810000
+max_batch_size: 10
+listen_addr: ":1000"
+lease:
+  ttl: 1m
+`)
+	t.Setenv("SNOWBALL_MAX_BATCH_SIZE", "20")
+	t.Setenv("SNOWBALL_LISTEN_ADDR", ":2000")
+	t.Setenv("SNOWBALL_RANDOM_SEQUENCE", "true")
+	t.Setenv("SNOWBALL_SIGNED_SAFE", "true")
+	t.Setenv("SNOWBALL_STATE_FILE", "/var/lib/snowball/state")
+
+	got, err := load("-config", path, "-listen-addr", ":3000")
+	if err != nil {
+		t.Fatalf("Error occurred running Load: %s", err)
+	}
+
+	tests := []struct {
+		key        string
+		got        any
+		want       any
+		wantSource string
+	}{
+		{"epoch_ms", got.EpochMs, uint64(1704121810000), "file " + path},
+		{"lease.ttl", got.Lease.TTL, time.Minute, "file " + path},
+		{"max_batch_size", got.MaxBatchSize, uint64(20), "env SNOWBALL_MAX_BATCH_SIZE"},
+		{"listen_addr", got.ListenAddr, ":3000", "flag -listen-addr"},
+		{"grpc_addr", got.GRPCAddr, ":9090", "default"},
+		{"random_sequence", got.RandomSequence, true, "env SNOWBALL_RANDOM_SEQUENCE"},
+		{"signed_safe", got.SignedSafe, true, "env SNOWBALL_SIGNED_SAFE"},
+		{"state_file", got.StateFile, "/var/lib/snowball/state", "env SNOWBALL_STATE_FILE"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %v, want: %v", tt.key, tt.got, tt.want)
+		}
+		if source := got.Source(tt.key); source != tt.wantSource {
+			t.Errorf("Source(%s) = %q, want: %q", tt.key, source, tt.wantSource)
+		}
+	}
+	// Epochs are checked against the layout of signed-safe IDs, whose timestamps run out sooner.
+	if got.Layout() != snowball.SignedLayout {
+		t.Errorf("Layout() = %+v, want: %+v", got.Layout(), snowball.SignedLayout)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "YAML",
+			file: "snowball.yml",
+			content: `
+server_id: 12
+server_id_sources: [env, ordinal]
+peers:
+  urls:
+    - http://snowball-0:8080
+    - http://snowball-1:8080
+`,
+		},
+		{
+			name: "TOML",
+			file: "snowball.toml",
+			content: `
+server_id = 12
+server_id_sources = ["env", "ordinal"]
+
+[peers]
+urls = ["http://snowball-0:8080", "http://snowball-1:8080"]
+`,
+		},
+		{
+			name:    "Unknown setting",
+			file:    "snowball.yaml",
+			content: "peers:\n  url: http://snowball-0:8080\n",
+			wantErr: "peers.url",
+		},
+		{name: "Unsupported format", file: "snowball.json", content: "{}", wantErr: "unsupported format"},
+		{name: "Malformed", file: "snowball.toml", content: "server_id = ", wantErr: "snowball.toml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("SNOWBALL_CONFIG", writeFile(t, tt.file, tt.content))
+
+			got, err := load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error: %v, want error mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error occurred running Load: %s", err)
+			}
+			if got.ServerId == nil || *got.ServerId != 12 {
+				t.Errorf("ServerId = %v, want: 12", got.ServerId)
+			}
+			if want := []string{"env", "ordinal"}; !slices.Equal(got.ServerIdSources, want) {
+				t.Errorf("ServerIdSources = %q, want: %q", got.ServerIdSources, want)
+			}
+			if want := []string{"http://snowball-0:8080", "http://snowball-1:8080"}; !slices.Equal(got.Peers.URLs, want) {
+				t.Errorf("Peers.URLs = %q, want: %q", got.Peers.URLs, want)
+			}
+		})
+	}
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("SNOWBALL_NODE_ID", "abc")
+	t.Setenv("SNOWBALL_SERVER_ID_SOURCES", "env,dns")
+	// The sum of offset and range wraps around to 0, which must not pass as a valid block.
+	t.Setenv("SNOWBALL_ORDINAL_OFFSET", "1")
+	t.Setenv("SNOWBALL_ORDINAL_RANGE", "18446744073709551615")
+	path := writeFile(t, "snowball.yaml", "drain_period: soon\nlease:\n  dir: /tmp\n  redis_addr: localhost:6379\n")
+
+	_, err := load("-config", path, "-ip-cidr", "10.0.0.0", "-log-level", "loud")
+	if err == nil {
+		t.Fatal("No error occurred running Load with invalid settings")
+	}
+
+	for _, want := range []string{
+		`server_id (from env SNOWBALL_NODE_ID): invalid value "abc"`,
+		`server_id_sources (from env SNOWBALL_SERVER_ID_SOURCES): unknown source "dns"`,
+		`drain_period (from file ` + path + `): invalid value "soon"`,
+		`lease.dir (from file ` + path + `): lease.dir and lease.redis_addr are exclusive`,
+		`ip.cidr (from flag -ip-cidr)`,
+		`log_level (from flag -log-level)`,
+		`ordinal.range (from env SNOWBALL_ORDINAL_RANGE)`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error does not contain %q:\n%s", want, err)
+		}
+	}
+	if n := strings.Count(err.Error(), "\n") + 1; n != 7 {
+		t.Errorf("Load() reported %d errors, want 7:\n%s", n, err)
+	}
+}
+
+func TestLoadLenient(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("SNOWBALL_NODE_ID", "3two")
+	t.Setenv("SNOWBALL_MAX_BATCH_SIZE", "lots")
+	t.Setenv("SNOWBALL_STRICT", "false")
+
+	got, err := load("-max-batch-size", "50")
+	if err != nil {
+		t.Fatalf("Error occurred running Load with strict disabled: %s", err)
+	}
+	if got.ServerId != nil || got.MaxBatchSize != 50 {
+		t.Errorf("Load() = server ID %v, max batch size %d, want: <nil>, 50", got.ServerId, got.MaxBatchSize)
+	}
+	if warnings := got.Warnings(); len(warnings) != 2 ||
+		!strings.Contains(warnings[0].Error(), "SNOWBALL_NODE_ID") {
+		t.Errorf("Warnings() = %v, want one for each malformed variable", warnings)
+	}
+
+	_, err = load("-strict")
+	if err == nil || !strings.Contains(err.Error(), `server_id (from env SNOWBALL_NODE_ID): invalid value "3two"`) {
+		t.Errorf("Load(-strict) error: %v, want an error naming SNOWBALL_NODE_ID", err)
+	}
+
+	// Implausible epochs are never ignored in favor of the default.
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810")
+	if _, err := load(); !errors.Is(err, snowball.ErrImplausibleEpoch) {
+		t.Errorf("Load() error with an implausible epoch: %v, want: %v", err, snowball.ErrImplausibleEpoch)
+	}
+}
+
+func TestLoadEpoch(t *testing.T) {
+	clearEnv(t)
+
+	got, err := load("-epoch-ms", "2024-01-01")
+	if err != nil {
+		t.Fatalf("Error occurred running Load: %s", err)
+	}
+	if got.EpochMs != 1704067200000 {
+		t.Errorf("EpochMs = %d, want: 1704067200000", got.EpochMs)
+	}
+
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704067200")
+	_, err = load()
+	if !errors.Is(err, snowball.ErrImplausibleEpoch) ||
+		!strings.Contains(err.Error(), "epoch_ms (from env SNOWBALL_EPOCH_MS)") {
+		t.Errorf("Load() error: %v, want an implausible epoch from SNOWBALL_EPOCH_MS", err)
+	}
+}
+
+func TestLoadNamespaces(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	path := writeFile(t, "snowball.yaml", `
+namespaces:
+  orders:
+    epoch_ms: 2024-01-01
+    server_id_offset: 1024
+    server_id_range: 1024
+  users:
+    server_id_offset: 1024
+`)
+
+	got, err := load("-config", path)
+	if err != nil {
+		t.Fatalf("Error occurred running Load: %s", err)
+	}
+	if names := got.NamespaceNames(); !slices.Equal(names, []string{"orders", "users"}) {
+		t.Fatalf("NamespaceNames() = %q, want: [orders users]", names)
+	}
+	orders, users := got.Namespaces["orders"], got.Namespaces["users"]
+	if orders.EpochMs != 1704067200000 || orders.ServerIdOffset != 1024 || orders.ServerIdRange != 1024 {
+		t.Errorf("Namespaces[orders] = %+v", orders)
+	}
+	if users.EpochMs != 1704121810000 || got.Source("namespaces.users.epoch_ms") != "env SNOWBALL_EPOCH_MS" {
+		t.Errorf("Namespaces[users] = %+v from %s, want the service's epoch",
+			users, got.Source("namespaces.users.epoch_ms"))
+	}
+	if id, err := orders.ServerId(32); id != 1056 || err != nil {
+		t.Errorf("Namespaces[orders].ServerId(32) = %d, %v, want: 1056", id, err)
+	}
+	if _, err := orders.ServerId(1024); err == nil {
+		t.Errorf("No error occurred running Namespaces[orders].ServerId with a server ID outside its range")
+	}
+
+	path = writeFile(t, "snowball.yaml", `
+namespaces:
+  Orders:
+    server_id_offset: 2000
+    server_id_range: 100
+  users:
+    epoch: 2024-01-01
+`)
+	_, err = load("-config", path)
+	for _, want := range []string{
+		"namespaces.Orders (from file", "namespaces.Orders.server_id_range", "namespaces.users.epoch",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error: %v, want error mentioning %q", err, want)
+		}
+	}
+
+	// Nodes sharing an epoch must not share server IDs, or they would issue the same IDs.
+	path = writeFile(t, "snowball.yaml", `
+namespaces:
+  billing:
+    epoch_ms: 2024-01-01
+    server_id_offset: 1500
+  orders:
+    epoch_ms: 2024-01-01
+    server_id_offset: 1024
+    server_id_range: 1024
+  users:
+    server_id_offset: 256
+    server_id_range: 512
+  wrapped:
+    epoch_ms: 2024-01-02
+    server_id_offset: 1
+    server_id_range: 18446744073709551615
+`)
+	_, err = load("-config", path)
+	for _, want := range []string{
+		"namespaces.orders.server_id_offset (from file", "overlap those of namespace billing",
+		"namespaces.users.server_id_offset (from file", "overlap the default node's server IDs 0 to 511",
+		"namespaces.wrapped.server_id_range (from file", "exceeds the maximum server ID",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error: %v, want error mentioning %q", err, want)
+		}
+	}
+}
+
+func TestLoadHelp(t *testing.T) {
+	clearEnv(t)
+	if _, err := load("-help"); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Load(-help) error: %v, want: %v", err, flag.ErrHelp)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("SNOWBALL_LEASE_REDIS_PASSWORD", "hunter2")
+
+	got, err := load("-max-batch-size", "50")
+	if err != nil {
+		t.Fatalf("Error occurred running Load: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := got.Print(&buf); err != nil {
+		t.Fatalf("Error occurred running Print: %s", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("Print() revealed the Redis password:\n%s", out)
+	}
+	for _, want := range []string{
+		`max_batch_size       = "50"`, "# flag -max-batch-size",
+		"# env SNOWBALL_LEASE_REDIS_PASSWORD", `listen_addr          = ":8080"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Print() output does not contain %q:\n%s", want, out)
+		}
+	}
+	if lines := strings.Count(out, "\n"); lines != len(settings) {
+		t.Errorf("Print() wrote %d lines, want one per setting (%d)", lines, len(settings))
+	}
+}
+
+func TestServerIDProvider(t *testing.T) {
+	serverId := uint64(32)
+	tests := []struct {
+		name       string
+		arg        Config
+		want       uint64
+		wantSource string
+		wantErr    error
+	}{
+		{
+			name:       "Server ID",
+			arg:        Config{ServerId: &serverId, ServerIdSources: []string{"ip", "env"}},
+			want:       32,
+			wantSource: "env",
+		},
+		{
+			name: "IP address",
+			arg: Config{
+				ServerIdSources: []string{"env", "ip"},
+				IP:              IPConfig{Address: "10.8.1.2", CIDR: "10.8.0.0/16"},
+			},
+			want:       258,
+			wantSource: "ip",
+		},
+		{
+			name: "Ordinal",
+			arg: Config{
+				ServerIdSources: []string{"env", "ordinal"},
+				Ordinal:         OrdinalConfig{Offset: 1024},
+			},
+			want:       1027,
+			wantSource: "ordinal",
+		},
+		{
+			name:    "None available",
+			arg:     Config{ServerIdSources: []string{"env", "ip"}},
+			wantErr: snowball.ErrServerIdUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOSTNAME", "snowball-3")
+			t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+
+			provider, err := tt.arg.ServerIDProvider()
+			if err != nil {
+				t.Fatalf("Error occurred running ServerIDProvider: %s", err)
+			}
+			node, err := snowball.InitNode(false, snowball.WithServerIDProvider(provider))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InitNode() error: %v, want: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if node.ServerId() != tt.want || node.ServerIdSource() != tt.wantSource {
+				t.Errorf("InitNode() server ID = %d from %s, want: %d from %s",
+					node.ServerId(), node.ServerIdSource(), tt.want, tt.wantSource)
+			}
+		})
+	}
+}
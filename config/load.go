@@ -0,0 +1,456 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// A setting, and how to read it from each source.
+type setting struct {
+	// Key in configuration files, with "." separating sections.
+	key string
+	// Environment variable, if any.
+	env string
+	// Command-line flag, if any.
+	flag  string
+	usage string
+	// Hidden when printing the configuration.
+	secret bool
+	// Registered as a boolean flag, which needs no value.
+	boolean bool
+
+	set func(c *Config, value string) error
+	get func(c *Config) string
+}
+
+func stringSetting(key, env, flag, usage string, field func(*Config) *string) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+		get: func(c *Config) string { return *field(c) },
+	}
+}
+
+func uintSetting(key, env, flag, usage string, field func(*Config) *uint64) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		set: func(c *Config, value string) error {
+			result, err := strconv.ParseUint(value, 10, 64)
+			if err == nil {
+				*field(c) = result
+			}
+			return err
+		},
+		get: func(c *Config) string { return strconv.FormatUint(*field(c), 10) },
+	}
+}
+
+func durationSetting(key, env, flag, usage string, field func(*Config) *time.Duration) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		set: func(c *Config, value string) error {
+			result, err := time.ParseDuration(value)
+			if err == nil {
+				*field(c) = result
+			}
+			return err
+		},
+		get: func(c *Config) string { return field(c).String() },
+	}
+}
+
+func boolSetting(key, env, flag, usage string, field func(*Config) *bool) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage, boolean: true,
+		set: func(c *Config, value string) error {
+			result, err := strconv.ParseBool(value)
+			if err == nil {
+				*field(c) = result
+			}
+			return err
+		},
+		get: func(c *Config) string { return strconv.FormatBool(*field(c)) },
+	}
+}
+
+// A setting holding a comma-separated list.
+func listSetting(key, env, flag, usage string, field func(*Config) *[]string) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		set: func(c *Config, value string) error {
+			var result []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					result = append(result, item)
+				}
+			}
+			*field(c) = result
+			return nil
+		},
+		get: func(c *Config) string { return strings.Join(*field(c), ",") },
+	}
+}
+
+var settings = []setting{
+	{
+		key: "epoch_ms", env: "SNOWBALL_EPOCH_MS", flag: "epoch-ms",
+		usage: "epoch that ID timestamps are relative to, as milliseconds since the Unix epoch, " +
+			"an RFC 3339 timestamp or a date (YYYY-MM-DD)",
+		set: func(c *Config, value string) error {
+			epoch, err := snowball.ParseEpoch(value)
+			if err == nil {
+				c.EpochMs = uint64(epoch.UnixMilli())
+			}
+			return err
+		},
+		get: func(c *Config) string { return strconv.FormatUint(c.EpochMs, 10) },
+	},
+	{
+		key: "server_id", env: "SNOWBALL_NODE_ID", flag: "server-id",
+		usage: "preassigned server ID, used by the env server ID source",
+		set: func(c *Config, value string) error {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err == nil {
+				c.ServerId = &id
+			}
+			return err
+		},
+		get: func(c *Config) string {
+			if c.ServerId == nil {
+				return ""
+			}
+			return strconv.FormatUint(*c.ServerId, 10)
+		},
+	},
+	listSetting("server_id_sources", "SNOWBALL_SERVER_ID_SOURCES", "server-id-sources",
+		"comma-separated server ID sources to try in order: env, ip, interface, ordinal",
+		func(c *Config) *[]string { return &c.ServerIdSources }),
+	stringSetting("ip.address", "SERVER_IP_ADDRESS", "ip-address",
+		"IP address to derive the server ID from, for the ip source",
+		func(c *Config) *string { return &c.IP.Address }),
+	uintSetting("ip.bits", "SNOWBALL_IP_BITS", "ip-bits",
+		"number of low address bits to use as the server ID (default 11)",
+		func(c *Config) *uint64 { return &c.IP.Bits }),
+	stringSetting("ip.cidr", "SNOWBALL_IP_CIDR", "ip-cidr",
+		"network addresses are allocated from; the server ID is the address's offset within it",
+		func(c *Config) *string { return &c.IP.CIDR }),
+	stringSetting("interface.name", "SNOWBALL_INTERFACE", "interface",
+		"network interface to detect the address of, for the interface source",
+		func(c *Config) *string { return &c.Interface.Name }),
+	stringSetting("interface.cidr", "SNOWBALL_INTERFACE_CIDR", "interface-cidr",
+		"only detect interface addresses within this network",
+		func(c *Config) *string { return &c.Interface.CIDR }),
+	stringSetting("ordinal.pattern", "SNOWBALL_ORDINAL_PATTERN", "ordinal-pattern",
+		"pattern matching the ordinal in the hostname, for the ordinal source (default -(\\d+)$)",
+		func(c *Config) *string { return &c.Ordinal.Pattern }),
+	uintSetting("ordinal.offset", "SNOWBALL_ORDINAL_OFFSET", "ordinal-offset",
+		"server ID of ordinal 0",
+		func(c *Config) *uint64 { return &c.Ordinal.Offset }),
+	uintSetting("ordinal.range", "SNOWBALL_ORDINAL_RANGE", "ordinal-range",
+		"number of server IDs reserved for ordinals, starting at the offset (default: up to the maximum)",
+		func(c *Config) *uint64 { return &c.Ordinal.Range }),
+	stringSetting("listen_addr", "SNOWBALL_LISTEN_ADDR", "listen-addr",
+		"address for the HTTP API, as host:port or unix:/path/to/socket",
+		func(c *Config) *string { return &c.ListenAddr }),
+	stringSetting("metrics_addr", "SNOWBALL_METRICS_ADDR", "metrics-addr",
+		"address for Prometheus metrics, as host:port or unix:/path/to/socket",
+		func(c *Config) *string { return &c.MetricsAddr }),
+	stringSetting("grpc_addr", "SNOWBALL_GRPC_ADDR", "grpc-addr",
+		"address for the gRPC API, as host:port or unix:/path/to/socket",
+		func(c *Config) *string { return &c.GRPCAddr }),
+	stringSetting("tls.cert_file", "SNOWBALL_TLS_CERT_FILE", "tls-cert-file",
+		"PEM certificate file; enables TLS on the HTTP and gRPC APIs, reloaded on SIGHUP",
+		func(c *Config) *string { return &c.TLS.CertFile }),
+	stringSetting("tls.key_file", "SNOWBALL_TLS_KEY_FILE", "tls-key-file",
+		"PEM private key file for the TLS certificate, reloaded on SIGHUP",
+		func(c *Config) *string { return &c.TLS.KeyFile }),
+	uintSetting("max_batch_size", "SNOWBALL_MAX_BATCH_SIZE", "max-batch-size",
+		"maximum number of IDs returned by a single request",
+		func(c *Config) *uint64 { return &c.MaxBatchSize }),
+	durationSetting("drain_period", "SNOWBALL_DRAIN_PERIOD", "drain-period",
+		"time to keep serving requests after a shutdown signal while failing readiness checks",
+		func(c *Config) *time.Duration { return &c.DrainPeriod }),
+	durationSetting("shutdown_timeout", "SNOWBALL_SHUTDOWN_TIMEOUT", "shutdown-timeout",
+		"time to wait for in-flight requests to finish when shutting down",
+		func(c *Config) *time.Duration { return &c.ShutdownTimeout }),
+	stringSetting("log_level", "SNOWBALL_LOG_LEVEL", "log-level",
+		"minimum level of log records: debug, info, warn or error",
+		func(c *Config) *string { return &c.LogLevel }),
+	boolSetting("random_sequence", "SNOWBALL_RANDOM_SEQUENCE", "random-sequence",
+		"start each millisecond's sequence at a random number, so the low bits of IDs are evenly distributed",
+		func(c *Config) *bool { return &c.RandomSequence }),
+	stringSetting("state_file", "SNOWBALL_STATE_FILE", "state-file",
+		"file to persist the time of the last issued ID in, so IDs keep increasing across restarts",
+		func(c *Config) *string { return &c.StateFile }),
+	boolSetting("signed_safe", "SNOWBALL_SIGNED_SAFE", "signed-safe",
+		"keep the top bit of IDs zero, so they fit in a signed 64-bit integer, halving the timestamp range",
+		func(c *Config) *bool { return &c.SignedSafe }),
+	boolSetting("strict", "SNOWBALL_STRICT", "strict",
+		"refuse to start if any setting is malformed; if false, malformed settings are ignored with a warning",
+		func(c *Config) *bool { return &c.Strict }),
+	stringSetting("lease.dir", "SNOWBALL_LEASE_DIR", "lease-dir",
+		"shared directory to lease a server ID from",
+		func(c *Config) *string { return &c.Lease.Dir }),
+	stringSetting("lease.redis_addr", "SNOWBALL_LEASE_REDIS_ADDR", "lease-redis-addr",
+		"Redis server to lease a server ID from, as host:port",
+		func(c *Config) *string { return &c.Lease.RedisAddr }),
+	{
+		key: "lease.redis_password", env: "SNOWBALL_LEASE_REDIS_PASSWORD", secret: true,
+		set: func(c *Config, value string) error {
+			c.Lease.RedisPassword = value
+			return nil
+		},
+		get: func(c *Config) string { return c.Lease.RedisPassword },
+	},
+	uintSetting("lease.redis_db", "SNOWBALL_LEASE_REDIS_DB", "lease-redis-db",
+		"Redis database to lease a server ID from",
+		func(c *Config) *uint64 { return &c.Lease.RedisDB }),
+	stringSetting("lease.redis_prefix", "SNOWBALL_LEASE_REDIS_PREFIX", "lease-redis-prefix",
+		"prefix of Redis lease keys (default snowball:server-id:)",
+		func(c *Config) *string { return &c.Lease.RedisPrefix }),
+	durationSetting("lease.ttl", "SNOWBALL_LEASE_TTL", "lease-ttl",
+		"time-to-live of server ID leases",
+		func(c *Config) *time.Duration { return &c.Lease.TTL }),
+	listSetting("peers.urls", "SNOWBALL_PEERS", "peers",
+		"comma-separated base URLs of peers to detect server ID collisions with",
+		func(c *Config) *[]string { return &c.Peers.URLs }),
+	durationSetting("peers.interval", "SNOWBALL_PEER_INTERVAL", "peer-interval",
+		"time between announcements to peers",
+		func(c *Config) *time.Duration { return &c.Peers.Interval }),
+	uintSetting("peers.max_missed", "SNOWBALL_PEER_MAX_MISSED", "peer-max-missed",
+		"consecutive rounds a colliding peer may fail to answer before the collision expires",
+		func(c *Config) *uint64 { return &c.Peers.MaxMissed }),
+	stringSetting("peers.self_url", "SNOWBALL_PEER_SELF_URL", "peer-self-url",
+		"base URL of this instance as listed in its peers' peers.urls (default: learned from peers.urls)",
+		func(c *Config) *string { return &c.Peers.SelfURL }),
+	{
+		key: "peers.secret", env: "SNOWBALL_PEER_SECRET", secret: true,
+		set: func(c *Config, value string) error {
+			c.Peers.Secret = value
+			return nil
+		},
+		get: func(c *Config) string { return c.Peers.Secret },
+	},
+}
+
+// Registers a flag for each setting, plus -config for the configuration file, on the flag set; parses the
+// arguments; and loads the configuration from the file named by -config or SNOWBALL_CONFIG, the environment and
+// the flags.
+//
+// Returns flag.ErrHelp if help was requested. If any setting is invalid, returns the configuration along with
+// an error listing every problem. If Strict is disabled, settings that cannot be parsed are instead ignored and
+// reported by Warnings, except for implausible epochs, which are always an error.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	flags := make(map[string]*flagValue)
+	for _, s := range settings {
+		if s.flag != "" {
+			usage := s.usage
+			if s.env != "" {
+				usage += " (env " + s.env + ")"
+			}
+			flags[s.flag] = &flagValue{boolean: s.boolean}
+			fs.Var(flags[s.flag], s.flag, usage)
+		}
+	}
+	path := fs.String("config", "", "YAML (.yaml, .yml) or TOML (.toml) configuration file (env SNOWBALL_CONFIG)")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	result := Default()
+	result.sources = make(map[string]string)
+	var malformed []error
+	apply := func(s setting, value, source string) {
+		if err := s.set(result, value); err != nil {
+			malformed = append(malformed,
+				fmt.Errorf("%s (from %s): invalid value %q: %w", s.key, source, value, unwrapNum(err)))
+			return
+		}
+		result.sources[s.key] = source
+	}
+
+	if *path == "" {
+		*path = os.Getenv("SNOWBALL_CONFIG")
+	}
+	if *path != "" {
+		values, err := readFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range settings {
+			if value, ok := values[s.key]; ok {
+				apply(s, value, "file "+*path)
+				delete(values, s.key)
+			}
+		}
+		for key, value := range values {
+			if ok, err := result.applyNamespace(key, value, "file "+*path); !ok {
+				malformed = append(malformed, fmt.Errorf("%s (from file %s): unknown setting", key, *path))
+			} else if err != nil {
+				malformed = append(malformed, err)
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && s.env != "" && value != "" {
+			apply(s, value, "env "+s.env)
+		}
+	}
+
+	visited := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { visited[f.Name] = true })
+	for _, s := range settings {
+		if s.flag != "" && visited[s.flag] {
+			apply(s, flags[s.flag].value, "flag -"+s.flag)
+		}
+	}
+
+	result.defaultNamespaceEpochs()
+
+	var errs []error
+	for _, err := range malformed {
+		// Ignoring an implausible epoch would switch to the default one, breaking the order of IDs already issued.
+		if result.Strict || errors.Is(err, snowball.ErrImplausibleEpoch) {
+			errs = append(errs, err)
+		} else {
+			result.warnings = append(result.warnings, err)
+		}
+	}
+	// Values that failed to parse were left unchanged, so are not reported again.
+	errs = append(errs, result.Validate())
+	return result, errors.Join(errs...)
+}
+
+// Returns the problems with settings that were ignored because Strict is disabled.
+func (c *Config) Warnings() []error {
+	return c.warnings
+}
+
+// Holds the value of a setting's flag until the other sources have been loaded.
+type flagValue struct {
+	value   string
+	boolean bool
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.boolean
+}
+
+// Strips the "strconv.ParseUint: parsing ..." prefix from number parsing errors, which repeat the value.
+func unwrapNum(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return numErr.Err
+	}
+	return err
+}
+
+// Reads a configuration file into a map from setting keys to values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	var tree map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format %q, must be .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	result := make(map[string]string)
+	flatten("", tree, result)
+	return result, nil
+}
+
+// Flattens nested sections into keys separated by ".", and formats values as they would be given in the
+// environment.
+func flatten(prefix string, tree map[string]any, result map[string]string) {
+	for key, value := range tree {
+		key = prefix + key
+		switch v := value.(type) {
+		case map[string]any:
+			if len(v) == 0 {
+				// Keep empty sections, such as a namespace using only defaults.
+				result[key] = ""
+			}
+			flatten(key+".", v, result)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			result[key] = strings.Join(items, ",")
+		case time.Time:
+			result[key] = v.Format(time.RFC3339Nano)
+		case nil:
+			result[key] = ""
+		default:
+			result[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// Writes the effective value and source of every setting, hiding secrets.
+func (c *Config) Print(w io.Writer) error {
+	width := 0
+	for _, s := range settings {
+		width = max(width, len(s.key))
+	}
+	for _, name := range c.NamespaceNames() {
+		for _, s := range namespaceSettings {
+			width = max(width, len("namespaces."+name+"."+s.key))
+		}
+	}
+
+	for _, s := range settings {
+		value := s.get(c)
+		if s.secret && value != "" {
+			value = "********"
+		}
+		if _, err := fmt.Fprintf(w, "%-*s = %-24q # %s\n", width, s.key, value, c.Source(s.key)); err != nil {
+			return err
+		}
+	}
+	for _, name := range c.NamespaceNames() {
+		for _, s := range namespaceSettings {
+			key := "namespaces." + name + "." + s.key
+			value := strconv.FormatUint(*s.field(c.Namespaces[name]), 10)
+			if _, err := fmt.Fprintf(w, "%-*s = %-24q # %s\n", width, key, value, c.Source(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Configuration of a namespace: a node hosted alongside the service's default node, with its own epoch and
+// block of server IDs, serving POST /generate/<name>. Namespaces are only configured in the configuration file,
+// under "namespaces.<name>".
+type NamespaceConfig struct {
+	// Epoch of the namespace's IDs, in milliseconds since the Unix epoch. Defaults to the service's epoch.
+	EpochMs uint64
+	// Server ID the service's server ID 0 maps onto. See ServerId.
+	ServerIdOffset uint64
+	// Number of server IDs reserved for the namespace, starting at ServerIdOffset. If 0, the block extends to
+	// the maximum server ID.
+	ServerIdRange uint64
+}
+
+// Names of namespaces: lowercase letters, digits, "-" and "_", so they are safe in URLs and metric labels.
+var namespaceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Returns the namespace's epoch.
+func (n *NamespaceConfig) Epoch() time.Time {
+	return time.UnixMilli(int64(n.EpochMs))
+}
+
+// Maps the service's server ID into the namespace's block: the namespace's server ID is ServerIdOffset plus
+// the service's server ID, which must be less than ServerIdRange.
+func (n *NamespaceConfig) ServerId(serverId uint64) (uint64, error) {
+	if n.ServerIdRange != 0 && serverId >= n.ServerIdRange {
+		return 0, fmt.Errorf("server ID %d is outside the namespace's range of %d server IDs",
+			serverId, n.ServerIdRange)
+	}
+	if maxServerId := uint64(snowball.MaxServerId); serverId <= maxServerId && n.ServerIdOffset <= maxServerId-serverId {
+		return n.ServerIdOffset + serverId, nil
+	}
+	return 0, fmt.Errorf("server ID %d with offset %d exceeds the maximum server ID %d",
+		serverId, n.ServerIdOffset, snowball.MaxServerId)
+}
+
+// Returns the names of the configured namespaces, in order.
+func (c *Config) NamespaceNames() []string {
+	result := make([]string, 0, len(c.Namespaces))
+	for name := range c.Namespaces {
+		result = append(result, name)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// A setting of a namespace, with its key relative to "namespaces.<name>".
+type namespaceSetting struct {
+	key   string
+	field func(*NamespaceConfig) *uint64
+	parse func(string) (uint64, error)
+}
+
+var namespaceSettings = []namespaceSetting{
+	{"epoch_ms", func(n *NamespaceConfig) *uint64 { return &n.EpochMs }, parseEpochMs},
+	{"server_id_offset", func(n *NamespaceConfig) *uint64 { return &n.ServerIdOffset }, parseUint},
+	{"server_id_range", func(n *NamespaceConfig) *uint64 { return &n.ServerIdRange }, parseUint},
+}
+
+func parseEpochMs(value string) (uint64, error) {
+	epoch, err := snowball.ParseEpoch(value)
+	return uint64(epoch.UnixMilli()), err
+}
+
+func parseUint(value string) (uint64, error) {
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// Applies a "namespaces.<name>.<setting>" value from the configuration file, or creates the namespace if the
+// key is just "namespaces.<name>" with no value. Reports whether the key names a namespace setting at all.
+func (c *Config) applyNamespace(key, value, source string) (bool, error) {
+	rest, ok := strings.CutPrefix(key, "namespaces.")
+	if !ok {
+		return false, nil
+	}
+	name, field, _ := strings.Cut(rest, ".")
+	i := slices.IndexFunc(namespaceSettings, func(s namespaceSetting) bool { return s.key == field })
+	if i < 0 && (field != "" || value != "") {
+		return false, nil
+	}
+
+	if c.Namespaces == nil {
+		c.Namespaces = make(map[string]*NamespaceConfig)
+	}
+	if c.Namespaces[name] == nil {
+		c.Namespaces[name] = &NamespaceConfig{}
+		c.sources["namespaces."+name] = source
+	}
+	if i < 0 {
+		// An empty namespace, using the defaults for all its settings.
+		return true, nil
+	}
+	result, err := namespaceSettings[i].parse(value)
+	if err != nil {
+		return true, fmt.Errorf("%s (from %s): invalid value %q: %w", key, source, value, unwrapNum(err))
+	}
+	*namespaceSettings[i].field(c.Namespaces[name]) = result
+	c.sources[key] = source
+	return true, nil
+}
+
+// Gives namespaces without an epoch the service's epoch.
+func (c *Config) defaultNamespaceEpochs() {
+	for name, n := range c.Namespaces {
+		if key := "namespaces." + name + ".epoch_ms"; c.sources[key] == "" {
+			n.EpochMs = c.EpochMs
+			c.sources[key] = c.Source("epoch_ms")
+		}
+	}
+}
+
+// Checks the configured namespaces, reporting problems through invalid.
+func (c *Config) validateNamespaces(invalid func(key, format string, args ...any)) {
+	for _, name := range c.NamespaceNames() {
+		n := c.Namespaces[name]
+		prefix := "namespaces." + name + "."
+		if !namespaceName.MatchString(name) {
+			invalid("namespaces."+name, "namespace names must consist of lowercase letters, digits, - and _")
+		}
+		if err := c.Layout().CheckEpoch(n.Epoch(), time.Now()); err != nil {
+			invalid(prefix+"epoch_ms", "%s", err)
+		}
+		if _, _, ok := n.block(); !ok {
+			invalid(prefix+"server_id_range", "block starting at %d with range %d exceeds the maximum server ID %d",
+				n.ServerIdOffset, n.ServerIdRange, snowball.MaxServerId)
+		}
+	}
+	c.validateNamespaceBlocks(invalid)
+}
+
+// Checks that no two nodes sharing an epoch, namespaces or the default node, can be assigned the same server ID,
+// since they would issue the same IDs.
+//
+// The default node's server ID can be anything below the smallest namespace range, as a larger one cannot be
+// mapped into that namespace's block and the service refuses to start.
+func (c *Config) validateNamespaceBlocks(invalid func(key, format string, args ...any)) {
+	defaultEnd := uint64(snowball.MaxServerId) + 1
+	for _, n := range c.Namespaces {
+		if n.ServerIdRange != 0 {
+			defaultEnd = min(defaultEnd, n.ServerIdRange)
+		}
+	}
+
+	names := c.NamespaceNames()
+	for i, name := range names {
+		n := c.Namespaces[name]
+		start, end, ok := n.block()
+		if !ok {
+			continue
+		}
+		key := "namespaces." + name + ".server_id_offset"
+		if n.EpochMs == c.EpochMs && start < defaultEnd {
+			invalid(key, "server IDs %d to %d overlap the default node's server IDs 0 to %d, which has the same epoch",
+				start, end-1, defaultEnd-1)
+		}
+		for _, other := range names[:i] {
+			o := c.Namespaces[other]
+			otherStart, otherEnd, ok := o.block()
+			if ok && n.EpochMs == o.EpochMs && start < otherEnd && otherStart < end {
+				invalid(key, "server IDs %d to %d overlap those of namespace %s, which has the same epoch",
+					start, end-1, other)
+			}
+		}
+	}
+}
+
+// Returns the namespace's block of server IDs, from start up to but excluding end, or false if it exceeds the
+// maximum server ID.
+func (n *NamespaceConfig) block() (start, end uint64, ok bool) {
+	maxServerId := uint64(snowball.MaxServerId)
+	// Compare the range against the room left after the offset, as their sum could overflow.
+	if n.ServerIdOffset > maxServerId || n.ServerIdRange > maxServerId+1-n.ServerIdOffset {
+		return 0, 0, false
+	}
+	if n.ServerIdRange == 0 {
+		return n.ServerIdOffset, maxServerId + 1, true
+	}
+	return n.ServerIdOffset, n.ServerIdOffset + n.ServerIdRange, true
+}
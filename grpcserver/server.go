@@ -0,0 +1,114 @@
+// Package grpcserver implements the snowballpb.IDGenerator gRPC service on top of a SnowballNode.
+package grpcserver
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/MrM21632/snowball/snowball"
+	"github.com/MrM21632/snowball/snowballpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Default upper bound on the number of IDs returned by a single GenerateBatch call.
+const DefaultMaxBatchSize = 1000
+
+// Serves the IDGenerator gRPC service using a single SnowballNode.
+type Server struct {
+	snowballpb.UnimplementedIDGeneratorServer
+
+	node         *snowball.SnowballNode
+	MaxBatchSize int
+}
+
+// Creates and returns a new server backed by the given node.
+func NewServer(node *snowball.SnowballNode) *Server {
+	return &Server{node: node, MaxBatchSize: DefaultMaxBatchSize}
+}
+
+// Creates a new server backed by the given node and registers it with the gRPC server.
+func Register(s grpc.ServiceRegistrar, node *snowball.SnowballNode) *Server {
+	server := NewServer(node)
+	snowballpb.RegisterIDGeneratorServer(s, server)
+	return server
+}
+
+func (s *Server) Generate(ctx context.Context, req *snowballpb.GenerateRequest) (*snowballpb.SnowballID, error) {
+	id, err := s.node.NextID()
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return s.toProto(id), nil
+}
+
+func (s *Server) GenerateBatch(
+	ctx context.Context, req *snowballpb.GenerateBatchRequest,
+) (*snowballpb.GenerateBatchResponse, error) {
+	if req.Count == 0 || int(req.Count) > s.MaxBatchSize {
+		return nil, status.Errorf(
+			codes.InvalidArgument, "count must be between 1 and %d, got %d", s.MaxBatchSize, req.Count,
+		)
+	}
+
+	ids, err := s.node.NextIDs(int(req.Count))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	result := &snowballpb.GenerateBatchResponse{Ids: make([]*snowballpb.SnowballID, len(ids))}
+	for i, id := range ids {
+		result.Ids[i] = s.toProto(id)
+	}
+	return result, nil
+}
+
+func (s *Server) Parse(ctx context.Context, req *snowballpb.ParseRequest) (*snowballpb.SnowballID, error) {
+	var id snowball.SnowballID
+	var err error
+	if req.Encoding == "" {
+		id, _, err = snowball.ParseID(req.Id)
+	} else {
+		var enc snowball.Encoding
+		if enc, err = snowball.ParseEncoding(req.Encoding); err == nil {
+			id, err = snowball.Decode(enc, req.Id)
+		}
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid id %q: %s", req.Id, err)
+	}
+
+	return s.toProto(id), nil
+}
+
+func (s *Server) GenerateStream(
+	req *snowballpb.GenerateStreamRequest, stream snowballpb.IDGenerator_GenerateStreamServer,
+) error {
+	for sent := uint32(0); req.Count == 0 || sent < req.Count; sent++ {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		id, err := s.node.NextID()
+		if err != nil {
+			return status.Error(codes.Unavailable, err.Error())
+		}
+		if err := stream.Send(s.toProto(id)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) toProto(id snowball.SnowballID) *snowballpb.SnowballID {
+	components := s.node.Decompose(id)
+	return &snowballpb.SnowballID{
+		Id:          uint64(id),
+		Decimal:     strconv.FormatUint(uint64(id), 10),
+		Timestamp:   timestamppb.New(components.Time),
+		TimestampMs: components.Timestamp,
+		ServerId:    uint32(components.ServerId),
+		Sequence:    uint32(components.Sequence),
+	}
+}
@@ -0,0 +1,177 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/MrM21632/snowball/snowball"
+	"github.com/MrM21632/snowball/snowballpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T) snowballpb.IDGeneratorClient {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, err := snowball.InitNode(false)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	Register(server, node)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Error occurred dialing bufconn: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return snowballpb.NewIDGeneratorClient(conn)
+}
+
+func TestGenerate(t *testing.T) {
+	client := newTestClient(t)
+
+	id, err := client.Generate(context.Background(), &snowballpb.GenerateRequest{})
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if id.ServerId != 32 {
+		t.Errorf("Generate() server ID: %v, want: 32", id.ServerId)
+	}
+	if id.Timestamp.AsTime().UnixMilli() != 1704121810000+int64(id.TimestampMs) {
+		t.Errorf("Generate() timestamp: %v does not match timestamp_ms: %v", id.Timestamp.AsTime(), id.TimestampMs)
+	}
+}
+
+func TestGenerateBatch(t *testing.T) {
+	client := newTestClient(t)
+
+	resp, err := client.GenerateBatch(context.Background(), &snowballpb.GenerateBatchRequest{Count: 500})
+	if err != nil {
+		t.Fatalf("GenerateBatch() error: %v", err)
+	}
+	if len(resp.Ids) != 500 {
+		t.Fatalf("GenerateBatch() returned %d IDs, want: 500", len(resp.Ids))
+	}
+	for i := 1; i < len(resp.Ids); i++ {
+		if resp.Ids[i].Id <= resp.Ids[i-1].Id {
+			t.Errorf("GenerateBatch() IDs %d and %d are not increasing", resp.Ids[i-1].Id, resp.Ids[i].Id)
+		}
+	}
+
+	for _, count := range []uint32{0, DefaultMaxBatchSize + 1} {
+		_, err = client.GenerateBatch(context.Background(), &snowballpb.GenerateBatchRequest{Count: count})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("GenerateBatch(%d) error: %v, want InvalidArgument", count, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	client := newTestClient(t)
+
+	id, err := client.Parse(context.Background(), &snowballpb.ParseRequest{Id: "19638199173316608"})
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if id.Id != 19638199173316608 || id.TimestampMs != 4682111543 || id.ServerId != 32 || id.Sequence != 0 {
+		t.Errorf("Parse() decoded: %v", id)
+	}
+
+	for _, req := range []*snowballpb.ParseRequest{
+		{Id: "1RwTVZKtLU"},
+		{Id: "0045c4d6-8dc1-8000-8000-000000000000"},
+		{Id: "1000101110001001101011010001101110000010000000000000000", Encoding: "binary"},
+	} {
+		id, err = client.Parse(context.Background(), req)
+		if err != nil || id.Id != 19638199173316608 {
+			t.Errorf("Parse(%v) decoded: %v (error: %v)", req, id, err)
+		}
+	}
+
+	for _, req := range []*snowballpb.ParseRequest{
+		{Id: "not+an+id"},
+		{Id: "123", Encoding: "base58"},
+		{Id: "zzzzzzzzzzzzzzzzzzzzzzzzzz"},
+		{Id: "AAAAAAAAAAAA===="},
+		{Id: "AAAAAAAAAA=="},
+		{Id: "AAAA", Encoding: "base64"},
+		{Id: "AAAAAAAA", Encoding: "base32"},
+	} {
+		_, err = client.Parse(context.Background(), req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Parse(%v) error: %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	client := newTestClient(t)
+
+	stream, err := client.GenerateStream(context.Background(), &snowballpb.GenerateStreamRequest{Count: 100})
+	if err != nil {
+		t.Fatalf("GenerateStream() error: %v", err)
+	}
+
+	var received int
+	var last uint64
+	for {
+		id, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("GenerateStream() error: %v", err)
+		}
+		if id.Id <= last {
+			t.Errorf("GenerateStream() IDs %d and %d are not increasing", last, id.Id)
+		}
+		last = id.Id
+		received++
+	}
+	if received != 100 {
+		t.Errorf("GenerateStream() received %d IDs, want: 100", received)
+	}
+}
+
+func TestGenerateStreamCancel(t *testing.T) {
+	client := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.GenerateStream(ctx, &snowballpb.GenerateStreamRequest{})
+	if err != nil {
+		t.Fatalf("GenerateStream() error: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("GenerateStream() error: %v", err)
+		}
+	}
+
+	cancel()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if status.Code(err) != codes.Canceled {
+				t.Errorf("GenerateStream() error: %v, want Canceled", err)
+			}
+			break
+		}
+	}
+}
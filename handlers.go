@@ -0,0 +1,168 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/MrM21632/snowball/snowball"
+	"github.com/gin-gonic/gin"
+)
+
+// Handles POST /generate, and POST /generate/:namespace for the nodes of namespaces. Without a count query
+// parameter, responds with a single ID; otherwise responds with count IDs reserved from the node in one batch.
+// IDs are formatted using the encoding requested by the client, along with their decimal form if that encoding
+// is not decimal. Responds with 503 while the node is suspended.
+func generateHandler(node *snowball.SnowballNode, maxBatchSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enc, err := requestedEncoding(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		countStr, ok := c.GetQuery("count")
+		if !ok {
+			id, err := node.NextID()
+			if err != nil {
+				c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+				return
+			}
+			result := gin.H{"encoding": enc, "id": encodeID(id, enc)}
+			if enc != snowball.EncodingDecimal {
+				result["decimal"] = encodeID(id, snowball.EncodingDecimal)
+			}
+			c.JSON(http.StatusOK, result)
+			return
+		}
+
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 1 || count > maxBatchSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "count must be an integer between 1 and " + strconv.Itoa(maxBatchSize),
+			})
+			return
+		}
+
+		ids, err := node.NextIDs(count)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		encoded := make([]string, len(ids))
+		for i, id := range ids {
+			encoded[i] = encodeID(id, enc)
+		}
+		result := gin.H{"encoding": enc, "ids": encoded}
+		if enc != snowball.EncodingDecimal {
+			decimals := make([]string, len(ids))
+			for i, id := range ids {
+				decimals[i] = encodeID(id, snowball.EncodingDecimal)
+			}
+			result["decimals"] = decimals
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// Determines the encoding requested by the client, from either the encoding query parameter or an encoding
+// parameter on the Accept header (e.g., "Accept: application/json; encoding=base62"). The query parameter takes
+// precedence, and decimal is used if neither is present.
+func requestedEncoding(c *gin.Context) (snowball.Encoding, error) {
+	if name, ok := c.GetQuery("encoding"); ok {
+		return snowball.ParseEncoding(name)
+	}
+
+	for _, accept := range c.Request.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			_, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil {
+				continue
+			}
+			if name, ok := params["encoding"]; ok {
+				return snowball.ParseEncoding(name)
+			}
+		}
+	}
+
+	return snowball.EncodingDecimal, nil
+}
+
+// Formats an ID using an encoding already validated by requestedEncoding.
+func encodeID(id snowball.SnowballID, enc snowball.Encoding) string {
+	result, _ := id.Encode(enc)
+	return result
+}
+
+// Format for timestamps in inspection responses: RFC 3339 with millisecond precision.
+const timestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Handles GET /ids/:id, and GET /namespaces/:namespace/ids/:id for the nodes of namespaces. Accepts the ID in
+// any supported encoding, detected automatically unless an encoding query parameter is given, and responds with
+// its sections and every alternative encoding.
+func inspectHandler(node *snowball.SnowballNode) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sid := c.Param("id")
+
+		var id snowball.SnowballID
+		var enc snowball.Encoding
+		var err error
+		if name, ok := c.GetQuery("encoding"); ok {
+			if enc, err = snowball.ParseEncoding(name); err == nil {
+				id, err = snowball.Decode(enc, sid)
+			}
+		} else {
+			id, enc, err = snowball.ParseID(sid)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		encodings := gin.H{}
+		for _, e := range snowball.Encodings {
+			encodings[string(e)] = encodeID(id, e)
+		}
+
+		components := node.Decompose(id)
+		result := gin.H{
+			"id":           encodeID(id, snowball.EncodingDecimal),
+			"encoding":     enc,
+			"timestamp":    components.Time.UTC().Format(timestampFormat),
+			"timestamp_ms": components.Timestamp,
+			"server_id":    components.ServerId,
+			"sequence":     components.Sequence,
+			"encodings":    encodings,
+		}
+		if node.Namespace() != "" {
+			result["namespace"] = node.Namespace()
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// Handles GET /healthz. The service is live as long as it can respond to requests.
+func livenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// Handles GET /readyz. The service is ready unless it is shutting down, or the node reports that it cannot
+// issue IDs (e.g., because its clock is behind or its server ID is unassigned).
+func readinessHandler(node interface{ Ready() error }, draining *atomic.Bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if draining.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+			return
+		}
+		if err := node.Ready(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/MrM21632/snowball/snowball"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, err := snowball.InitNode(false)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/generate", generateHandler(node, 100))
+	r.GET("/ids/:id", inspectHandler(node))
+	return r
+}
+
+func TestGenerateHandler(t *testing.T) {
+	r := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/generate", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /generate status: %d, want: %d", w.Code, http.StatusOK)
+	}
+
+	var body struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Id == "" {
+		t.Errorf("POST /generate body: %s (error: %v)", w.Body.String(), err)
+	}
+}
+
+func TestGenerateHandlerSuspended(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+	node, _ := snowball.InitNode(false)
+	node.Suspend(errors.New("lease lost"))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/generate", generateHandler(node, 100))
+
+	for _, target := range []string{"/generate", "/generate?count=10"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("POST %s status: %d, want: %d", target, w.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func TestGenerateHandlerBatch(t *testing.T) {
+	r := newTestRouter(t)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		wantLen  int
+	}{
+		{name: "Valid count", query: "?count=100", wantCode: http.StatusOK, wantLen: 100},
+		{name: "Count of 1", query: "?count=1", wantCode: http.StatusOK, wantLen: 1},
+		{name: "Count above maximum", query: "?count=101", wantCode: http.StatusBadRequest},
+		{name: "Count of 0", query: "?count=0", wantCode: http.StatusBadRequest},
+		{name: "Non-integer count", query: "?count=ten", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/generate"+tt.query, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("POST /generate%s status: %d, want: %d", tt.query, w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Ids []string `json:"ids"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || len(body.Ids) != tt.wantLen {
+				t.Errorf("POST /generate%s body: %s (error: %v)", tt.query, w.Body.String(), err)
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerEncoding(t *testing.T) {
+	r := newTestRouter(t)
+
+	tests := []struct {
+		name     string
+		query    string
+		accept   string
+		wantCode int
+		wantEnc  snowball.Encoding
+	}{
+		{name: "Default", wantCode: http.StatusOK, wantEnc: snowball.EncodingDecimal},
+		{name: "Query parameter", query: "?encoding=base62", wantCode: http.StatusOK, wantEnc: snowball.EncodingBase62},
+		{
+			name:     "Accept header",
+			accept:   "text/html, application/json; encoding=hex",
+			wantCode: http.StatusOK,
+			wantEnc:  snowball.EncodingHex,
+		},
+		{
+			name:     "Query parameter overrides Accept header",
+			query:    "?encoding=uuid",
+			accept:   "application/json; encoding=hex",
+			wantCode: http.StatusOK,
+			wantEnc:  snowball.EncodingUUID,
+		},
+		{name: "Unknown encoding", query: "?encoding=base58", wantCode: http.StatusBadRequest},
+		{name: "Unknown encoding in Accept", accept: "application/json; encoding=rot13", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/generate"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("POST /generate status: %d, want: %d (body: %s)", w.Code, tt.wantCode, w.Body.String())
+			}
+
+			var body struct {
+				Encoding snowball.Encoding `json:"encoding"`
+				Id       string            `json:"id"`
+				Decimal  string            `json:"decimal"`
+				Error    string            `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("POST /generate body: %s (error: %v)", w.Body.String(), err)
+			}
+			if tt.wantCode != http.StatusOK {
+				if body.Error == "" {
+					t.Errorf("POST /generate body has no error message: %s", w.Body.String())
+				}
+				return
+			}
+
+			if body.Encoding != tt.wantEnc {
+				t.Errorf("POST /generate encoding: %v, want: %v", body.Encoding, tt.wantEnc)
+			}
+			id, err := snowball.Decode(tt.wantEnc, body.Id)
+			if err != nil {
+				t.Fatalf("POST /generate returned undecodable ID %q: %v", body.Id, err)
+			}
+			if tt.wantEnc != snowball.EncodingDecimal && encodeID(id, snowball.EncodingDecimal) != body.Decimal {
+				t.Errorf("POST /generate decimal: %v, want: %v", body.Decimal, id)
+			}
+		})
+	}
+}
+
+func TestGenerateHandlerBatchEncoding(t *testing.T) {
+	r := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/generate?count=10&encoding=base64", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /generate status: %d, want: %d", w.Code, http.StatusOK)
+	}
+
+	var body struct {
+		Ids      []string `json:"ids"`
+		Decimals []string `json:"decimals"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || len(body.Ids) != 10 || len(body.Decimals) != 10 {
+		t.Fatalf("POST /generate body: %s (error: %v)", w.Body.String(), err)
+	}
+	for i := range body.Ids {
+		id, err := snowball.FromBase64(body.Ids[i])
+		if err != nil || encodeID(id, snowball.EncodingDecimal) != body.Decimals[i] {
+			t.Errorf("POST /generate ID %q does not match decimal %q", body.Ids[i], body.Decimals[i])
+		}
+	}
+}
+
+func TestInspectHandler(t *testing.T) {
+	r := newTestRouter(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantEnc  snowball.Encoding
+	}{
+		{name: "Decimal", path: "/ids/19638199173316613", wantCode: http.StatusOK, wantEnc: snowball.EncodingDecimal},
+		{name: "Base62", path: "/ids/1RwTVZKtLZ", wantCode: http.StatusOK, wantEnc: snowball.EncodingBase62},
+		{
+			name:     "UUID",
+			path:     "/ids/0045c4d6-8dc1-8000-9400-000000000000",
+			wantCode: http.StatusOK,
+			wantEnc:  snowball.EncodingUUID,
+		},
+		{
+			name:     "Explicit encoding",
+			path:     "/ids/1000101110001001101011010001101110000010000000000000101?encoding=binary",
+			wantCode: http.StatusOK,
+			wantEnc:  snowball.EncodingBinary,
+		},
+		{name: "Invalid ID", path: "/ids/not+an+id", wantCode: http.StatusBadRequest},
+		{name: "Overflowing base62", path: "/ids/zzzzzzzzzzzzzzzzzzzzzzzzzz", wantCode: http.StatusBadRequest},
+		{name: "Truncated base32", path: "/ids/AAAAAAAAAAAA====", wantCode: http.StatusBadRequest},
+		{name: "Truncated base64", path: "/ids/AAAAAAAAAA==", wantCode: http.StatusBadRequest},
+		{name: "Short base64", path: "/ids/AAAA?encoding=base64", wantCode: http.StatusBadRequest},
+		{name: "Unknown encoding", path: "/ids/123?encoding=base58", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("GET %s status: %d, want: %d (body: %s)", tt.path, w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Id        string            `json:"id"`
+				Encoding  snowball.Encoding `json:"encoding"`
+				Timestamp string            `json:"timestamp"`
+				ServerId  uint64            `json:"server_id"`
+				Sequence  uint64            `json:"sequence"`
+				Encodings map[string]string `json:"encodings"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("GET %s body: %s (error: %v)", tt.path, w.Body.String(), err)
+			}
+			if body.Id != "19638199173316613" || body.Encoding != tt.wantEnc || body.ServerId != 32 ||
+				body.Sequence != 5 || body.Timestamp != "2024-02-24T19:45:21.543Z" {
+				t.Errorf("GET %s body: %s", tt.path, w.Body.String())
+			}
+			if len(body.Encodings) != len(snowball.Encodings) || body.Encodings["base62"] != "1RwTVZKtLZ" {
+				t.Errorf("GET %s encodings: %v", tt.path, body.Encodings)
+			}
+		})
+	}
+}
+
+func TestHealthHandlers(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+	assigned, _ := snowball.InitNode(false)
+
+	suspended, _ := snowball.InitNode(false)
+	suspended.Suspend(nil)
+
+	t.Setenv("SNOWBALL_NODE_ID", "")
+	unassigned, _ := snowball.InitNode(false)
+
+	tests := []struct {
+		name     string
+		node     *snowball.SnowballNode
+		draining bool
+		path     string
+		wantCode int
+	}{
+		{name: "Live", node: assigned, path: "/healthz", wantCode: http.StatusOK},
+		{name: "Live while draining", node: assigned, draining: true, path: "/healthz", wantCode: http.StatusOK},
+		{name: "Ready", node: assigned, path: "/readyz", wantCode: http.StatusOK},
+		{name: "Draining", node: assigned, draining: true, path: "/readyz", wantCode: http.StatusServiceUnavailable},
+		{name: "Unassigned server ID", node: unassigned, path: "/readyz", wantCode: http.StatusServiceUnavailable},
+		{name: "Suspended", node: suspended, path: "/readyz", wantCode: http.StatusServiceUnavailable},
+	}
+
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var draining atomic.Bool
+			draining.Store(tt.draining)
+
+			r := gin.New()
+			r.GET("/healthz", livenessHandler())
+			r.GET("/readyz", readinessHandler(tt.node, &draining))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("GET %s status: %d, want: %d (body: %s)", tt.path, w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package lease
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Leases server IDs through files in a directory shared by all nodes, e.g. a network file system mount.
+//
+// Each leased server ID has a file named after it holding the lease's token and expiry. Access to the
+// directory is serialized with an advisory lock on a ".lock" file, so the file system must support flock(2)
+// across all nodes. Expiry times are compared using each node's wall clock, so the nodes' clocks must be
+// synchronized to well within the lease ttl.
+type FileAllocator struct {
+	dir string
+}
+
+// The contents of a lease file.
+type leaseFile struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+	Holder  string    `json:"holder,omitempty"`
+}
+
+// Creates and returns an allocator using the given directory, creating it if necessary.
+func NewFileAllocator(dir string) (*FileAllocator, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("lease directory: %w", err)
+	}
+	return &FileAllocator{dir: dir}, nil
+}
+
+func (a *FileAllocator) Acquire(ctx context.Context, ttl time.Duration) (Lease, error) {
+	unlock, err := a.lock(ctx)
+	if err != nil {
+		return Lease{}, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	for id := uint64(0); id <= uint64(snowball.MaxServerId); id++ {
+		current, err := a.read(id)
+		if err != nil {
+			return Lease{}, err
+		}
+		if current != nil && current.Expires.After(now) {
+			continue
+		}
+
+		result := Lease{ServerId: id, Token: newToken(), Expires: now.Add(ttl)}
+		if err := a.write(result); err != nil {
+			return Lease{}, err
+		}
+		return result, nil
+	}
+
+	return Lease{}, ErrNoFreeServerId
+}
+
+func (a *FileAllocator) Renew(ctx context.Context, lease Lease, ttl time.Duration) (Lease, error) {
+	unlock, err := a.lock(ctx)
+	if err != nil {
+		return Lease{}, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	current, err := a.read(lease.ServerId)
+	if err != nil {
+		return Lease{}, err
+	}
+	// An expired lease that has not been claimed by another node is still ours to renew.
+	if current == nil || current.Token != lease.Token {
+		return Lease{}, ErrLeaseLost
+	}
+
+	lease.Expires = now.Add(ttl)
+	if err := a.write(lease); err != nil {
+		return Lease{}, err
+	}
+	return lease, nil
+}
+
+func (a *FileAllocator) Release(ctx context.Context, lease Lease) error {
+	unlock, err := a.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := a.read(lease.ServerId)
+	if err != nil || current == nil || current.Token != lease.Token {
+		return err
+	}
+	if err := os.Remove(a.path(lease.ServerId)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("release lease failed: %w", err)
+	}
+	return nil
+}
+
+func (a *FileAllocator) path(serverId uint64) string {
+	return filepath.Join(a.dir, strconv.FormatUint(serverId, 10)+".lease")
+}
+
+// Reads the lease file for a server ID, returning nil if there is none. Must be called with the directory
+// locked.
+func (a *FileAllocator) read(serverId uint64) (*leaseFile, error) {
+	data, err := os.ReadFile(a.path(serverId))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read lease failed: %w", err)
+	}
+
+	var result leaseFile
+	if err := json.Unmarshal(data, &result); err != nil {
+		// A corrupt file (e.g. from a crash mid-write on a file system without atomic renames) is treated
+		// as expired rather than blocking the server ID forever.
+		return &leaseFile{}, nil
+	}
+	return &result, nil
+}
+
+// Writes the lease file for a lease, replacing any previous one atomically. Must be called with the directory
+// locked.
+func (a *FileAllocator) write(lease Lease) error {
+	holder, _ := os.Hostname()
+	data, err := json.Marshal(leaseFile{Token: lease.Token, Expires: lease.Expires, Holder: holder})
+	if err != nil {
+		return err
+	}
+
+	tmp := a.path(lease.ServerId) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write lease failed: %w", err)
+	}
+	if err := os.Rename(tmp, a.path(lease.ServerId)); err != nil {
+		return fmt.Errorf("write lease failed: %w", err)
+	}
+	return nil
+}
+
+// Takes the exclusive lock on the directory, polling until it is available or the context is done. Returns a
+// function that releases the lock.
+func (a *FileAllocator) lock(ctx context.Context) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(a.dir, ".lock"), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("lock lease directory failed: %w", err)
+	}
+
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock lease directory failed: %w", err)
+		}
+		if ok {
+			return func() {
+				unlock(f)
+				f.Close()
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, fmt.Errorf("lock lease directory failed: %w", ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+func TestFileAllocator(t *testing.T) {
+	allocator, err := NewFileAllocator(t.TempDir())
+	if err != nil {
+		t.Fatalf("Error occurred running NewFileAllocator: %s", err)
+	}
+	testAllocator(t, allocator)
+}
+
+func TestFileAllocatorExpiry(t *testing.T) {
+	dir := t.TempDir()
+	first, _ := NewFileAllocator(dir)
+	second, _ := NewFileAllocator(dir)
+	ctx := context.Background()
+
+	expired, err := first.Acquire(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Error occurred running Acquire: %s", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// An expired lease can be renewed while no other node has claimed its server ID...
+	if expired, err = first.Renew(ctx, expired, time.Millisecond); err != nil {
+		t.Fatalf("Renew() of an unclaimed expired lease error: %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// ...but once it has, the original holder has lost it.
+	claimed, err := second.Acquire(ctx, time.Minute)
+	if err != nil {
+		t.Fatalf("Error occurred running Acquire: %s", err)
+	}
+	if claimed.ServerId != expired.ServerId {
+		t.Errorf("Acquire() server ID: %v, want expired server ID: %v", claimed.ServerId, expired.ServerId)
+	}
+	if _, err := first.Renew(ctx, expired, time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Renew() of a claimed lease error: %v, want: %v", err, ErrLeaseLost)
+	}
+}
+
+func TestFileAllocatorExhausted(t *testing.T) {
+	allocator, _ := NewFileAllocator(t.TempDir())
+	expires := time.Now().Add(time.Minute)
+	for id := uint64(0); id <= uint64(snowball.MaxServerId); id++ {
+		if err := allocator.write(Lease{ServerId: id, Token: "held", Expires: expires}); err != nil {
+			t.Fatalf("Error occurred writing lease: %s", err)
+		}
+	}
+
+	if _, err := allocator.Acquire(context.Background(), time.Minute); !errors.Is(err, ErrNoFreeServerId) {
+		t.Errorf("Acquire() error: %v, want: %v", err, ErrNoFreeServerId)
+	}
+
+	// A corrupt lease file does not block its server ID.
+	if err := os.WriteFile(allocator.path(5), []byte("{"), 0o644); err != nil {
+		t.Fatalf("Error occurred writing lease: %s", err)
+	}
+	lease, err := allocator.Acquire(context.Background(), time.Minute)
+	if err != nil || lease.ServerId != 5 {
+		t.Errorf("Acquire() = %v (error: %v), want server ID 5", lease.ServerId, err)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package lease
+
+import (
+	"errors"
+	"os"
+)
+
+func tryLock(f *os.File) (bool, error) {
+	return false, errors.New("file leases are not supported on this platform")
+}
+
+func unlock(f *os.File) {}
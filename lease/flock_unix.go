@@ -0,0 +1,22 @@
+//go:build unix
+
+package lease
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Attempts to take an exclusive advisory lock on the file without blocking.
+func tryLock(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func unlock(f *os.File) {
+	syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
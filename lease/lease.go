@@ -0,0 +1,191 @@
+// Package lease assigns server IDs dynamically by leasing them from a shared coordination backend, so that
+// nodes in an autoscaling group can each claim a free server ID in 0..snowball.MaxServerId instead of having
+// one assigned statically.
+//
+// A node acquires a lease for a server ID with a time-to-live, renews it periodically while it runs, and
+// releases it on shutdown. If a lease cannot be renewed before it expires, another node may claim the same
+// server ID, so the node must stop generating IDs; Keeper takes care of renewing the lease and suspending the
+// node when it can no longer be sure it holds the lease.
+//
+// Two backends are provided: FileAllocator, which coordinates through lease files in a directory shared by
+// all nodes, and RedisAllocator, which coordinates through keys in a Redis (or Redis-compatible) server.
+package lease
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+var (
+	ErrNoFreeServerId = errors.New("acquire lease failed: no free server ID")
+	ErrLeaseLost      = errors.New("lease lost: server ID is no longer held by this node")
+	ErrLeaseExpired   = errors.New("lease expired: could not renew before the lease ran out")
+)
+
+// Default ttl of leases acquired by the service.
+const DefaultTTL = 30 * time.Second
+
+// A claim on a server ID, valid until it expires unless renewed.
+type Lease struct {
+	ServerId uint64
+	// Identifies the holder of the lease; renewing or releasing requires the same token.
+	Token string
+	// The time until which the lease is guaranteed to be held, as measured by this node's clock.
+	Expires time.Time
+}
+
+// Hands out leases on server IDs from a shared coordination backend.
+type ServerIDAllocator interface {
+	// Claims a server ID that is not currently leased for ttl. Returns ErrNoFreeServerId if every server ID
+	// is leased.
+	Acquire(ctx context.Context, ttl time.Duration) (Lease, error)
+	// Extends the lease by ttl from now. Returns ErrLeaseLost if the lease has been claimed by another node.
+	Renew(ctx context.Context, lease Lease, ttl time.Duration) (Lease, error)
+	// Gives up the lease so the server ID can be claimed by another node. Releasing a lease that has been
+	// lost is not an error.
+	Release(ctx context.Context, lease Lease) error
+}
+
+// Creates and returns a random lease token.
+func newToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// Implemented by *snowball.SnowballNode.
+type Suspender interface {
+	Suspend(reason error) (resume func())
+}
+
+// Holds a lease on behalf of a node: renews it in the background and suspends the node if it is lost.
+//
+// The keeper is a snowball.ServerIDProvider for the leased server ID, so it can be passed to
+// snowball.InitNode with snowball.WithServerIDProvider.
+type Keeper struct {
+	// Logger for renewal failures; defaults to snowball.Logger().
+	Logger *slog.Logger
+
+	allocator ServerIDAllocator
+	ttl       time.Duration
+
+	mutex sync.Mutex
+	lease Lease
+}
+
+// Acquires a lease from the allocator and returns a keeper for it. The lease is not renewed until Run is
+// called.
+func Acquire(ctx context.Context, allocator ServerIDAllocator, ttl time.Duration) (*Keeper, error) {
+	if ttl < 5*time.Millisecond {
+		return nil, fmt.Errorf("acquire lease failed: ttl %s is too short", ttl)
+	}
+
+	lease, err := allocator.Acquire(ctx, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if lease.ServerId > uint64(snowball.MaxServerId) {
+		return nil, fmt.Errorf("acquire lease failed: server ID %d is out of range", lease.ServerId)
+	}
+	return &Keeper{allocator: allocator, ttl: ttl, lease: lease}, nil
+}
+
+func (k *Keeper) ServerId() (uint64, error) {
+	return k.Lease().ServerId, nil
+}
+
+func (k *Keeper) String() string {
+	return "lease"
+}
+
+// Returns the lease currently held.
+func (k *Keeper) Lease() Lease {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	return k.lease
+}
+
+// Renews the lease every fifth of its ttl until the context is done or the lease is lost.
+//
+// If renewals keep failing (e.g. because the backend is unreachable) and the lease could run out before the
+// next attempt completes, the node is suspended with ErrLeaseExpired while a safety margin of a fifth of the
+// ttl remains, since another node may claim the server ID once the lease runs out; it is resumed if a later
+// renewal succeeds. If the backend reports that the lease
+// is held by another node, the node is suspended with ErrLeaseLost and Run returns that error. Returns nil
+// once the context is done.
+func (k *Keeper) Run(ctx context.Context, node Suspender) error {
+	interval := k.ttl / 5
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var resume func()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		err := k.renew(ctx, interval)
+		if err != nil && ctx.Err() == nil {
+			k.log().Warn("server ID lease renewal failed",
+				slog.Uint64("server_id", k.Lease().ServerId),
+				slog.Any("error", err),
+			)
+		}
+		switch {
+		case err == nil:
+			if resume != nil {
+				resume()
+				resume = nil
+			}
+		case errors.Is(err, ErrLeaseLost):
+			node.Suspend(err)
+			return err
+		case ctx.Err() != nil:
+			return nil
+		case resume == nil && time.Until(k.Lease().Expires) < 3*interval:
+			resume = node.Suspend(fmt.Errorf("%w: %w", ErrLeaseExpired, err))
+		}
+	}
+}
+
+func (k *Keeper) log() *slog.Logger {
+	if k.Logger != nil {
+		return k.Logger
+	}
+	return snowball.Logger()
+}
+
+func (k *Keeper) renew(ctx context.Context, timeout time.Duration) error {
+	current := k.Lease()
+
+	// Bound each attempt so a hanging backend cannot delay suspension past the lease's expiry.
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	renewed, err := k.allocator.Renew(ctx, current, k.ttl)
+	if err != nil {
+		return err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.lease = renewed
+	return nil
+}
+
+// Releases the lease. The node must have stopped generating IDs first.
+func (k *Keeper) Release(ctx context.Context) error {
+	return k.allocator.Release(ctx, k.Lease())
+}
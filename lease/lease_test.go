@@ -0,0 +1,191 @@
+package lease
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Allocator whose renewals fail with a configurable error, for exercising Keeper.
+type fakeAllocator struct {
+	mutex    sync.Mutex
+	renewErr error
+	released bool
+}
+
+func (a *fakeAllocator) Acquire(ctx context.Context, ttl time.Duration) (Lease, error) {
+	return Lease{ServerId: 7, Token: "token", Expires: time.Now().Add(ttl)}, nil
+}
+
+func (a *fakeAllocator) Renew(ctx context.Context, lease Lease, ttl time.Duration) (Lease, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.renewErr != nil {
+		return Lease{}, a.renewErr
+	}
+	lease.Expires = time.Now().Add(ttl)
+	return lease, nil
+}
+
+func (a *fakeAllocator) Release(ctx context.Context, lease Lease) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.released = true
+	return nil
+}
+
+func (a *fakeAllocator) setRenewErr(err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.renewErr = err
+}
+
+// Records suspensions, for exercising Keeper.
+type fakeNode struct {
+	mutex     sync.Mutex
+	suspended error
+}
+
+func (n *fakeNode) Suspend(reason error) func() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.suspended = reason
+
+	return func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		n.suspended = nil
+	}
+}
+
+func (n *fakeNode) err() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.suspended
+}
+
+// Polls until the condition holds, failing the test if it doesn't within a second.
+func eventually(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+	t.Fatalf("Timed out waiting for %s", what)
+}
+
+func TestKeeper(t *testing.T) {
+	allocator := &fakeAllocator{}
+	keeper, err := Acquire(context.Background(), allocator, 300*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Error occurred running Acquire: %s", err)
+	}
+	if id, _ := keeper.ServerId(); id != 7 {
+		t.Errorf("ServerId() = %v, want: 7", id)
+	}
+
+	node := &fakeNode{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- keeper.Run(ctx, node) }()
+
+	// Renewals keep the lease alive.
+	initial := keeper.Lease().Expires
+	eventually(t, "renewal", func() bool { return keeper.Lease().Expires.After(initial) })
+	if err := node.err(); err != nil {
+		t.Errorf("Node suspended while renewals succeed: %v", err)
+	}
+
+	// Failing renewals suspend the node before the lease expires, and it resumes once renewals succeed again.
+	allocator.setRenewErr(errors.New("connection refused"))
+	eventually(t, "suspension", func() bool { return errors.Is(node.err(), ErrLeaseExpired) })
+	if time.Now().After(keeper.Lease().Expires) {
+		t.Errorf("Node suspended after the lease expired")
+	}
+	allocator.setRenewErr(nil)
+	eventually(t, "resumption", func() bool { return node.err() == nil })
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error: %v, want: nil", err)
+	}
+
+	if err := keeper.Release(context.Background()); err != nil || !allocator.released {
+		t.Errorf("Release() error: %v, released: %v", err, allocator.released)
+	}
+}
+
+func TestKeeperLeaseLost(t *testing.T) {
+	allocator := &fakeAllocator{renewErr: ErrLeaseLost}
+	keeper, err := Acquire(context.Background(), allocator, 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Error occurred running Acquire: %s", err)
+	}
+
+	node := &fakeNode{}
+	if err := keeper.Run(context.Background(), node); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Run() error: %v, want: %v", err, ErrLeaseLost)
+	}
+	if !errors.Is(node.err(), ErrLeaseLost) {
+		t.Errorf("Node suspended with: %v, want: %v", node.err(), ErrLeaseLost)
+	}
+}
+
+// Runs the tests common to every allocator.
+func testAllocator(t *testing.T, allocator ServerIDAllocator) {
+	ctx := context.Background()
+	ttl := time.Minute
+
+	// Concurrent nodes claim distinct server IDs.
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	leases := map[uint64]Lease{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lease, err := allocator.Acquire(ctx, ttl)
+			if err != nil {
+				t.Errorf("Acquire() error: %v", err)
+				return
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if _, ok := leases[lease.ServerId]; ok {
+				t.Errorf("Acquire() returned server ID %d twice", lease.ServerId)
+			}
+			leases[lease.ServerId] = lease
+		}()
+	}
+	wg.Wait()
+
+	for _, lease := range leases {
+		stolen := lease
+		stolen.Token = "stolen"
+		if _, err := allocator.Renew(ctx, stolen, ttl); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("Renew() with another token error: %v, want: %v", err, ErrLeaseLost)
+		}
+		if err := allocator.Release(ctx, stolen); err != nil {
+			t.Errorf("Release() with another token error: %v", err)
+		}
+
+		renewed, err := allocator.Renew(ctx, lease, ttl)
+		if err != nil {
+			t.Fatalf("Renew() error: %v", err)
+		}
+		if renewed.ServerId != lease.ServerId || renewed.Token != lease.Token {
+			t.Errorf("Renew() = %+v, want the same lease as %+v", renewed, lease)
+		}
+
+		if err := allocator.Release(ctx, lease); err != nil {
+			t.Errorf("Release() error: %v", err)
+		}
+		if _, err := allocator.Renew(ctx, lease, ttl); !errors.Is(err, ErrLeaseLost) {
+			t.Errorf("Renew() after Release error: %v, want: %v", err, ErrLeaseLost)
+		}
+	}
+}
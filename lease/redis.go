@@ -0,0 +1,236 @@
+package lease
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Default prefix of the keys RedisAllocator stores leases under.
+const DefaultRedisKeyPrefix = "snowball:server-id:"
+
+// Renews a lease if it is still held with the given token. Returns 1 if renewed, 0 otherwise.
+const renewScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then ` +
+	`return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+
+// Deletes a lease if it is still held with the given token. Returns 1 if deleted, 0 otherwise.
+const releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then ` +
+	`return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// Leases server IDs through keys in a Redis server, or any server speaking the Redis protocol with support for
+// SET NX PX and Lua scripts (e.g. Valkey or KeyDB).
+//
+// Each leased server ID is a key holding the lease's token, which Redis expires once the lease runs out, so
+// expiry does not depend on the nodes' clocks. A connection is opened for each operation; leases are renewed
+// infrequently enough that pooling is not worth its complexity.
+type RedisAllocator struct {
+	// Address of the server, as "host:port".
+	Addr string
+	// Password to authenticate with, if any.
+	Password string
+	// Database to select, if not the default of 0.
+	DB int
+	// Prefix of the lease keys; defaults to DefaultRedisKeyPrefix.
+	KeyPrefix string
+}
+
+// Creates and returns an allocator for the Redis server at the given address, using the default database and
+// key prefix.
+func NewRedisAllocator(addr string) *RedisAllocator {
+	return &RedisAllocator{Addr: addr}
+}
+
+func (a *RedisAllocator) Acquire(ctx context.Context, ttl time.Duration) (Lease, error) {
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return Lease{}, fmt.Errorf("acquire lease failed: %w", err)
+	}
+	defer conn.Close()
+
+	// Start at a random server ID so that nodes starting at the same time don't all contend for the first one.
+	count := uint64(snowball.MaxServerId) + 1
+	offset := rand.Uint64N(count)
+	token := newToken()
+	for i := uint64(0); i < count; i++ {
+		id := (offset + i) % count
+
+		start := time.Now()
+		reply, err := conn.do("SET", a.key(id), token, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+		if err != nil {
+			return Lease{}, fmt.Errorf("acquire lease failed: %w", err)
+		}
+		if reply != nil {
+			return Lease{ServerId: id, Token: token, Expires: start.Add(ttl)}, nil
+		}
+	}
+
+	return Lease{}, ErrNoFreeServerId
+}
+
+func (a *RedisAllocator) Renew(ctx context.Context, lease Lease, ttl time.Duration) (Lease, error) {
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return Lease{}, fmt.Errorf("renew lease failed: %w", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	reply, err := conn.do(
+		"EVAL", renewScript, "1", a.key(lease.ServerId), lease.Token, strconv.FormatInt(ttl.Milliseconds(), 10),
+	)
+	if err != nil {
+		return Lease{}, fmt.Errorf("renew lease failed: %w", err)
+	}
+	if reply != int64(1) {
+		return Lease{}, ErrLeaseLost
+	}
+
+	lease.Expires = start.Add(ttl)
+	return lease, nil
+}
+
+func (a *RedisAllocator) Release(ctx context.Context, lease Lease) error {
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("release lease failed: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.do("EVAL", releaseScript, "1", a.key(lease.ServerId), lease.Token); err != nil {
+		return fmt.Errorf("release lease failed: %w", err)
+	}
+	return nil
+}
+
+func (a *RedisAllocator) key(serverId uint64) string {
+	prefix := a.KeyPrefix
+	if prefix == "" {
+		prefix = DefaultRedisKeyPrefix
+	}
+	return prefix + strconv.FormatUint(serverId, 10)
+}
+
+// A connection speaking the Redis serialization protocol (RESP).
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Opens a connection to the server, authenticating and selecting the database as configured. The
+// connection's deadline is taken from the context.
+func (a *RedisAllocator) dial(ctx context.Context) (*redisConn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", a.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	result := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+	if a.Password != "" {
+		if _, err := result.do("AUTH", a.Password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if a.DB != 0 {
+		if _, err := result.do("SELECT", strconv.Itoa(a.DB)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+// An error reply from the server.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// Sends a command and returns its reply: a string for simple and bulk strings, an int64 for integers, a []any
+// for arrays, and nil for null replies. Error replies are returned as errors.
+func (c *redisConn) do(args ...string) (any, error) {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		return nil, err
+	}
+
+	return readReply(c.reader)
+}
+
+// Reads a single RESP reply.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		result := make([]any, n)
+		for i := range result {
+			if result[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+// Reads a CRLF-terminated line, without the terminator.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("redis: malformed reply %q", line)
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,192 @@
+package lease
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// In-process server implementing the subset of the Redis protocol used by RedisAllocator, including the two
+// Lua scripts it sends.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mutex   sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error occurred listening: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	result := &fakeRedis{
+		listener: listener,
+		password: password,
+		values:   map[string]string{},
+		expires:  map[string]time.Time{},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go result.serve(conn)
+		}
+	}()
+	return result
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := f.password == ""
+
+	for {
+		request, err := readReply(reader)
+		if err != nil {
+			return
+		}
+		parts, _ := request.([]any)
+		args := make([]string, len(parts))
+		for i, part := range parts {
+			args[i], _ = part.(string)
+		}
+
+		var reply string
+		switch {
+		case len(args) == 0:
+			reply = "-ERR empty command\r\n"
+		case args[0] == "AUTH":
+			authenticated = args[1] == f.password
+			reply = "+OK\r\n"
+			if !authenticated {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		default:
+			reply = f.execute(args)
+		}
+
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) execute(args []string) string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch {
+	case args[0] == "SELECT":
+		return "+OK\r\n"
+	case args[0] == "SET" && len(args) == 6 && args[3] == "NX" && args[4] == "PX":
+		if _, ok := f.get(args[1]); ok {
+			return "$-1\r\n"
+		}
+		ms, _ := strconv.Atoi(args[5])
+		f.values[args[1]] = args[2]
+		f.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return "+OK\r\n"
+	case args[0] == "EVAL" && args[1] == renewScript:
+		if value, ok := f.get(args[3]); !ok || value != args[4] {
+			return ":0\r\n"
+		}
+		ms, _ := strconv.Atoi(args[5])
+		f.expires[args[3]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return ":1\r\n"
+	case args[0] == "EVAL" && args[1] == releaseScript:
+		if value, ok := f.get(args[3]); !ok || value != args[4] {
+			return ":0\r\n"
+		}
+		delete(f.values, args[3])
+		return ":1\r\n"
+	}
+
+	return "-ERR unsupported command\r\n"
+}
+
+// Returns the value of an unexpired key. Must be called with the mutex held.
+func (f *fakeRedis) get(key string) (string, bool) {
+	value, ok := f.values[key]
+	if ok && time.Now().After(f.expires[key]) {
+		delete(f.values, key)
+		return "", false
+	}
+	return value, ok
+}
+
+func (f *fakeRedis) set(key, value string, ttl time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.values[key] = value
+	f.expires[key] = time.Now().Add(ttl)
+}
+
+func TestRedisAllocator(t *testing.T) {
+	server := newFakeRedis(t, "hunter2")
+	allocator := &RedisAllocator{Addr: server.listener.Addr().String(), Password: "hunter2", DB: 3}
+	testAllocator(t, allocator)
+}
+
+func TestRedisAllocatorExpiry(t *testing.T) {
+	server := newFakeRedis(t, "")
+	allocator := NewRedisAllocator(server.listener.Addr().String())
+	ctx := context.Background()
+
+	lease, err := allocator.Acquire(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Error occurred running Acquire: %s", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	// Redis expires the key, so an expired lease is lost even if no other node has claimed it yet.
+	if _, err := allocator.Renew(ctx, lease, time.Minute); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Renew() of an expired lease error: %v, want: %v", err, ErrLeaseLost)
+	}
+}
+
+func TestRedisAllocatorExhausted(t *testing.T) {
+	server := newFakeRedis(t, "")
+	allocator := &RedisAllocator{Addr: server.listener.Addr().String(), KeyPrefix: "ids:"}
+	for id := uint64(0); id <= uint64(snowball.MaxServerId); id++ {
+		if id != 1234 {
+			server.set("ids:"+strconv.FormatUint(id, 10), "held", time.Minute)
+		}
+	}
+
+	lease, err := allocator.Acquire(context.Background(), time.Minute)
+	if err != nil || lease.ServerId != 1234 {
+		t.Fatalf("Acquire() = %v (error: %v), want server ID 1234", lease.ServerId, err)
+	}
+	if _, err := allocator.Acquire(context.Background(), time.Minute); !errors.Is(err, ErrNoFreeServerId) {
+		t.Errorf("Acquire() error: %v, want: %v", err, ErrNoFreeServerId)
+	}
+}
+
+func TestRedisAllocatorErrors(t *testing.T) {
+	server := newFakeRedis(t, "hunter2")
+	allocator := &RedisAllocator{Addr: server.listener.Addr().String(), Password: "wrong"}
+	if _, err := allocator.Acquire(context.Background(), time.Minute); err == nil {
+		t.Errorf("No error occurred running Acquire with the wrong password")
+	}
+
+	allocator = NewRedisAllocator(server.listener.Addr().String())
+	if _, err := allocator.Acquire(context.Background(), time.Minute); err == nil {
+		t.Errorf("No error occurred running Acquire without authenticating")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Opens a listener for the given address. Addresses of the form "unix:/path/to/socket" (or
+// "unix:///path/to/socket") listen on a Unix domain socket, replacing any stale socket left behind at that
+// path; all other addresses are TCP "host:port" addresses.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	path = strings.TrimPrefix(path, "//")
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// Serves a TLS certificate and key loaded from files, which can be reloaded (e.g., on SIGHUP) without
+// restarting the service.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mutex sync.RWMutex
+	cert  *tls.Certificate
+}
+
+// Creates a reloader and performs the initial load of the certificate and key.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("tls: both a certificate file and a key file must be provided")
+	}
+
+	result := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := result.Reload(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Loads the certificate and key from their files again. If loading fails, the previous certificate remains
+// in use.
+func (r *certReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cert = &cert
+	return nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.cert, nil
+}
+
+// Returns a TLS configuration that always presents the most recently loaded certificate.
+func (r *certReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snowball.sock")
+
+	for _, addr := range []string{"unix:" + path, "unix://" + path} {
+		listener, err := listen(addr)
+		if err != nil {
+			t.Fatalf("listen(%q) error: %v", addr, err)
+		}
+		if listener.Addr().Network() != "unix" {
+			t.Errorf("listen(%q) network: %v, want: unix", addr, listener.Addr().Network())
+		}
+
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			t.Fatalf("Dial(%q) error: %v", path, err)
+		}
+		conn.Close()
+
+		// Leave the socket file behind, as a crashed process would; the next listen must replace it.
+		if l, ok := listener.(*net.UnixListener); ok {
+			l.SetUnlinkOnClose(false)
+		}
+		listener.Close()
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	listener, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen() error: %v", err)
+	}
+	defer listener.Close()
+
+	if listener.Addr().Network() != "tcp" {
+		t.Errorf("listen() network: %v, want: tcp", listener.Addr().Network())
+	}
+}
+
+// Writes a new self-signed certificate and key with the given common name to the given files.
+func writeTestCert(t *testing.T, certFile, keyFile, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error: %v", err)
+	}
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := os.WriteFile(certFile, certPem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	if _, err := newCertReloader(certFile, ""); err == nil {
+		t.Errorf("newCertReloader() without key file did not fail")
+	}
+	if _, err := newCertReloader(certFile, keyFile); err == nil {
+		t.Errorf("newCertReloader() with missing files did not fail")
+	}
+
+	writeTestCert(t, certFile, keyFile, "first")
+	certs, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("newCertReloader() error: %v", err)
+	}
+	assertCommonName(t, certs, "first")
+
+	writeTestCert(t, certFile, keyFile, "second")
+	if err := certs.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	assertCommonName(t, certs, "second")
+
+	os.WriteFile(keyFile, []byte("not a key"), 0o600)
+	if err := certs.Reload(); err == nil {
+		t.Errorf("Reload() with invalid key did not fail")
+	}
+	assertCommonName(t, certs, "second")
+}
+
+func assertCommonName(t *testing.T, certs *certReloader, want string) {
+	t.Helper()
+
+	cert, err := certs.TLSConfig().GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate() error: %v", err)
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() error: %v", err)
+	}
+	if parsed.Subject.CommonName != want {
+		t.Errorf("GetCertificate() common name: %v, want: %v", parsed.Subject.CommonName, want)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLogger(&buf, "INFO")
+	if err != nil {
+		t.Fatalf("Error occurred running newLogger: %s", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(requestLogger(logger))
+	r.GET("/ids/:id", func(c *gin.Context) { c.Status(http.StatusTeapot) })
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ids/42", nil))
+
+	var record struct {
+		Msg    string `json:"msg"`
+		Route  string `json:"route"`
+		Status int    `json:"status"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Request log is not JSON: %q (error: %v)", buf.String(), err)
+	}
+	if record.Route != "/ids/:id" || record.Status != http.StatusTeapot {
+		t.Errorf("Request log = %+v, want route /ids/:id and status %d", record, http.StatusTeapot)
+	}
+
+	if _, err := newLogger(&buf, "verbose"); err == nil {
+		t.Errorf("No error occurred running newLogger with an unknown level")
+	}
+}
@@ -1,13 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"strconv"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"github.com/MrM21632/snowball/config"
+	"github.com/MrM21632/snowball/grpcserver"
+	"github.com/MrM21632/snowball/lease"
+	"github.com/MrM21632/snowball/peers"
 	"github.com/MrM21632/snowball/snowball"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func prometheusHandler() gin.HandlerFunc {
@@ -18,26 +33,296 @@ func prometheusHandler() gin.HandlerFunc {
 }
 
 func main() {
-	// Change to false if you want to provide pre-assigned IDs for servers
-	node, err := snowball.InitNode(true)
+	fs := flag.NewFlagSet("snowball", flag.ContinueOnError)
+	printConfig := fs.Bool("print-config", false,
+		"print the effective configuration and where each value came from, then exit")
+	cfg, err := config.Load(fs, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if *printConfig && cfg != nil {
+		cfg.Print(os.Stdout)
+	}
 	if err != nil {
-		fmt.Printf("Encountered error while initializing node: %s", err)
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%s\n", err)
+		os.Exit(2)
+	} else if *printConfig {
 		return
 	}
 
-	r := gin.Default() // Request routing
-	p := gin.New()     // Metrics routing
+	logger, err := newLogger(os.Stdout, cfg.LogLevel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	snowball.SetLogger(logger)
+	slog.SetDefault(logger)
+	for _, err := range cfg.Warnings() {
+		slog.Warn("ignoring malformed setting", slog.Any("error", err))
+	}
+	if _, ok := os.LookupEnv(gin.EnvGinMode); !ok {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	var certs *certReloader
+	if cfg.TLS.CertFile != "" {
+		if certs, err = newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile); err != nil {
+			slog.Error("loading TLS certificate failed", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+
+	nodeMetrics := snowball.NewMetrics()
+	nodeOpts := []snowball.Option{snowball.WithMetrics(nodeMetrics), snowball.WithEpoch(cfg.Epoch())}
+	groupOpts := []snowball.Option{snowball.WithMetrics(nodeMetrics), snowball.WithLogger(logger)}
+	if cfg.RandomSequence {
+		nodeOpts = append(nodeOpts, snowball.WithRandomSequenceStart())
+		groupOpts = append(groupOpts, snowball.WithRandomSequenceStart())
+	}
+	if cfg.SignedSafe {
+		nodeOpts = append(nodeOpts, snowball.WithSignedSafe())
+		groupOpts = append(groupOpts, snowball.WithSignedSafe())
+	}
+	if cfg.StateFile != "" {
+		// All nodes share the state, as it holds the time of the last ID issued by any of them.
+		state := snowball.WithStateFile(cfg.StateFile)
+		nodeOpts = append(nodeOpts, state)
+		groupOpts = append(groupOpts, state)
+	}
+
+	// If a lease backend is configured, claim a free server ID from it rather than using the configured sources.
+	keeper, err := acquireLease(cfg)
+	if err != nil {
+		slog.Error("acquiring server ID lease failed", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if keeper != nil {
+		nodeOpts = append(nodeOpts, snowball.WithServerIDProvider(keeper))
+	} else {
+		provider, err := cfg.ServerIDProvider()
+		if err != nil {
+			slog.Error("configuring server ID sources failed", slog.Any("error", err))
+			os.Exit(1)
+		}
+		nodeOpts = append(nodeOpts, snowball.WithServerIDProvider(provider))
+	}
+
+	node, err := snowball.InitNode(false, nodeOpts...)
+	if err != nil {
+		slog.Error("initializing node failed", slog.Any("error", err))
+		exit(nil, keeper)
+	}
 
+	// Host a node for each namespace, with its own epoch and its server ID derived from the default node's.
+	nodes, err := newNodeGroup(node, cfg, groupOpts...)
+	if err != nil {
+		slog.Error("initializing namespaces failed", slog.Any("error", err))
+		exit(node, keeper)
+	}
+	if keeper != nil {
+		defer releaseLease(nodes, keeper)
+	}
+
+	// Attach the node's identity to everything logged from here on.
+	logger = logger.With(
+		slog.Uint64("server_id", node.ServerId()),
+		slog.String("server_id_source", node.ServerIdSource()),
+		slog.Time("epoch", node.Epoch()),
+	)
+	snowball.SetLogger(logger)
+	slog.SetDefault(logger)
+
+	requestMetrics := newHTTPMetrics(node.ServerId())
+	prometheus.MustRegister(nodeMetrics, requestMetrics)
+
+	r := gin.New() // Request routing
+	p := gin.New() // Metrics routing
+
+	r.Use(requestLogger(logger), gin.Recovery())
 	p.Use(prometheusHandler())
 	r.SetTrustedProxies(nil)
+	r.Use(requestMetrics.middleware())
+
+	var draining atomic.Bool
+	r.POST("/generate", generateHandler(node, int(cfg.MaxBatchSize)))
+	r.GET("/ids/:id", inspectHandler(node))
+	r.POST("/generate/:namespace", namespaceHandler(nodes.namespaces, func(node *snowball.SnowballNode) gin.HandlerFunc {
+		return generateHandler(node, int(cfg.MaxBatchSize))
+	}))
+	r.GET("/namespaces/:namespace/ids/:id", namespaceHandler(nodes.namespaces, inspectHandler))
+	r.GET("/healthz", livenessHandler())
+	r.GET("/readyz", readinessHandler(nodes, &draining))
+
+	// If peers are configured, exchange announcements with them to detect server ID collisions.
+	var detector *peers.Detector
+	if len(cfg.Peers.URLs) > 0 {
+		detector = peers.NewDetector(nodes, cfg.Peers.URLs)
+		detector.Interval = cfg.Peers.Interval
+		detector.MaxMissed = int(cfg.Peers.MaxMissed)
+		detector.URL = cfg.Peers.SelfURL
+		detector.Secret = cfg.Peers.Secret
+		r.POST(peers.AnnouncePath, gin.WrapH(detector))
+	}
+
+	var grpcOpts []grpc.ServerOption
+	if certs != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(certs.TLSConfig())))
+	}
+	g := grpc.NewServer(grpcOpts...) // gRPC routing
+	grpcserver.Register(g, node).MaxBatchSize = int(cfg.MaxBatchSize)
+
+	errorLog := slog.NewLogLogger(logger.Handler(), slog.LevelError)
+	apiServer := &http.Server{Handler: r, ErrorLog: errorLog}
+	metricsServer := &http.Server{Handler: p, ErrorLog: errorLog}
+	if certs != nil {
+		apiServer.TLSConfig = certs.TLSConfig()
+	}
+
+	apiListener, err := listen(cfg.ListenAddr)
+	if err != nil {
+		slog.Error("listening failed", slog.String("addr", cfg.ListenAddr), slog.Any("error", err))
+		exit(nodes, keeper)
+	}
+	metricsListener, err := listen(cfg.MetricsAddr)
+	if err != nil {
+		slog.Error("listening failed", slog.String("addr", cfg.MetricsAddr), slog.Any("error", err))
+		exit(nodes, keeper)
+	}
+	grpcListener, err := listen(cfg.GRPCAddr)
+	if err != nil {
+		slog.Error("listening failed", slog.String("addr", cfg.GRPCAddr), slog.Any("error", err))
+		exit(nodes, keeper)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if certs != nil {
+		go reloadOnHangup(ctx, certs)
+	}
+	if detector != nil {
+		go detector.Run(ctx)
+	}
+	if keeper != nil {
+		go func() {
+			if err := keeper.Run(ctx, nodes); err != nil {
+				slog.Error("stopped generating IDs", slog.Any("error", err))
+			}
+		}()
+	}
+
+	errs := make(chan error, 3)
+	go func() {
+		errs <- ignoreServerClosed(metricsServer.Serve(metricsListener))
+	}()
+	go func() {
+		errs <- g.Serve(grpcListener)
+	}()
+	go func() {
+		if certs != nil {
+			errs <- ignoreServerClosed(apiServer.ServeTLS(apiListener, "", ""))
+		} else {
+			errs <- ignoreServerClosed(apiServer.Serve(apiListener))
+		}
+	}()
+
+	slog.Info("serving requests",
+		slog.String("listen_addr", cfg.ListenAddr),
+		slog.String("grpc_addr", cfg.GRPCAddr),
+		slog.String("metrics_addr", cfg.MetricsAddr),
+		slog.Bool("tls", certs != nil),
+	)
+	select {
+	case <-ctx.Done():
+	case err := <-errs:
+		slog.Error("serving requests failed", slog.Any("error", err))
+		exit(nodes, keeper)
+	}
+	slog.Info("shutting down", slog.Duration("drain_period", cfg.DrainPeriod))
+
+	// Fail readiness checks while continuing to serve requests, then stop accepting new connections and
+	// wait for in-flight requests to complete.
+	draining.Store(true)
+	time.Sleep(cfg.DrainPeriod)
 
-	r.POST("/generate", func(c *gin.Context) {
-		id := node.GenerateID()
-		c.JSON(http.StatusOK, gin.H{"id": strconv.FormatUint(uint64(id), 10)})
-	})
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
+	stopped := make(chan struct{})
 	go func() {
-		p.Run(":9100")
+		g.GracefulStop()
+		close(stopped)
 	}()
-	r.Run(":8080")
+	if err := apiServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutting down API server failed", slog.Any("error", err))
+	}
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutting down metrics server failed", slog.Any("error", err))
+	}
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		g.Stop()
+	}
+}
+
+// Reloads the TLS certificate and key whenever the process receives SIGHUP, until the context is done.
+func reloadOnHangup(ctx context.Context, certs *certReloader) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			if err := certs.Reload(); err != nil {
+				slog.Error("reloading TLS certificate failed, keeping previous one", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Acquires a server ID lease from the configured backend, if any. Returns nil if leasing is not enabled.
+func acquireLease(cfg *config.Config) (*lease.Keeper, error) {
+	allocator, err := cfg.Allocator()
+	if err != nil || allocator == nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Lease.TTL)
+	defer cancel()
+	return lease.Acquire(ctx, allocator, cfg.Lease.TTL)
+}
+
+// Stops the nodes from generating IDs and releases its server ID lease so another node can claim it. node is nil
+// if the lease is released before any node was created.
+func releaseLease(node lease.Suspender, keeper *lease.Keeper) {
+	if node != nil {
+		node.Suspend(errors.New("shutting down"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := keeper.Release(ctx); err != nil {
+		slog.Error("releasing server ID lease failed", slog.Any("error", err))
+	}
+}
+
+// Exits with status 1 after a failure, releasing the server ID lease first, if one was acquired, since deferred
+// calls do not run on os.Exit.
+func exit(node lease.Suspender, keeper *lease.Keeper) {
+	if keeper != nil {
+		releaseLease(node, keeper)
+	}
+	os.Exit(1)
+}
+
+// Treats the error returned by a server after a graceful shutdown as a clean exit.
+func ignoreServerClosed(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus collector for metrics about requests to the HTTP API.
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newHTTPMetrics(serverId uint64) *httpMetrics {
+	constLabels := prometheus.Labels{"server_id": strconv.FormatUint(serverId, 10)}
+	return &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "snowball",
+			Subsystem:   "http",
+			Name:        "requests_total",
+			Help:        "Number of HTTP requests handled, by route, method and status code.",
+			ConstLabels: constLabels,
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   "snowball",
+			Subsystem:   "http",
+			Name:        "request_duration_seconds",
+			Help:        "Time taken to handle HTTP requests, by route and method.",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+	}
+}
+
+func (m *httpMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.requests.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+func (m *httpMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.requests.Collect(ch)
+	m.duration.Collect(ch)
+}
+
+// Records the outcome and duration of every request handled by the router.
+func (m *httpMetrics) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// Label by route pattern rather than path, so IDs in paths don't create unbounded label values.
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		m.requests.WithLabelValues(route, method, strconv.Itoa(c.Writer.Status())).Inc()
+		m.duration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	}
+}
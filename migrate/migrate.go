@@ -0,0 +1,172 @@
+// Package migrate converts IDs produced by other Snowflake-style generators (Twitter Snowflake, Discord
+// and Sonyflake) into Snowball IDs and back, to ease migrating existing services onto Snowball.
+//
+// Conversion goes through Components, which hold the absolute generation time, node and sequence of an
+// ID independently of any epoch or bit layout. Decomposing an ID never fails; re-encoding it fails with
+// an error wrapping snowball.ErrSectionOverflow when a component cannot fit in the target layout (e.g.,
+// a 12-bit Snowflake sequence of 2048 or more into the 11-bit Snowball sequence), or with ErrBeforeEpoch
+// when the ID predates the target epoch.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+var ErrBeforeEpoch = errors.New("convert failed: ID was generated before the target epoch")
+
+// Describes the layout and epoch of a Snowflake-style ID scheme. Sections are packed from the most
+// significant bit downward, timestamp first.
+type Scheme struct {
+	Name string
+	// The time that timestamp 0 corresponds to.
+	Epoch time.Time
+	// The duration of one tick of the timestamp section.
+	TimeUnit time.Duration
+
+	TimestampLen uint8
+	NodeLen      uint8
+	SequenceLen  uint8
+	// Sonyflake places the sequence before the node (machine ID); the other schemes place it last.
+	SequenceFirst bool
+}
+
+// Twitter's original Snowflake: a 41-bit millisecond timestamp, 10-bit node (5-bit datacenter ID and
+// 5-bit worker ID), and 12-bit sequence, with an epoch of 2010-11-04T01:42:54.657Z.
+var Twitter = Scheme{
+	Name:         "twitter",
+	Epoch:        time.UnixMilli(1288834974657).UTC(),
+	TimeUnit:     time.Millisecond,
+	TimestampLen: 41,
+	NodeLen:      10,
+	SequenceLen:  12,
+}
+
+// Discord's Snowflake variant: a 41-bit millisecond timestamp, 10-bit node (5-bit internal worker ID and
+// 5-bit internal process ID), and 12-bit increment, with an epoch of 2015-01-01T00:00:00Z.
+var Discord = Scheme{
+	Name:         "discord",
+	Epoch:        time.UnixMilli(1420070400000).UTC(),
+	TimeUnit:     time.Millisecond,
+	TimestampLen: 41,
+	NodeLen:      10,
+	SequenceLen:  12,
+}
+
+// Sonyflake: a 39-bit timestamp in units of 10 milliseconds, 8-bit sequence, and 16-bit machine ID, with
+// the default start time of 2014-09-01T00:00:00Z. Copy and adjust Epoch for generators configured with a
+// custom start time.
+var Sonyflake = Scheme{
+	Name:          "sonyflake",
+	Epoch:         time.Date(2014, time.September, 1, 0, 0, 0, 0, time.UTC),
+	TimeUnit:      10 * time.Millisecond,
+	TimestampLen:  39,
+	NodeLen:       16,
+	SequenceLen:   8,
+	SequenceFirst: true,
+}
+
+// The epoch- and layout-independent parts of an ID.
+type Components struct {
+	// The time the ID was generated, truncated to the precision of its scheme.
+	Time     time.Time
+	Node     uint64
+	Sequence uint64
+}
+
+// Unpacks an ID generated under the scheme into its components.
+func (s Scheme) Decompose(id uint64) Components {
+	var node, sequence uint64
+	if s.SequenceFirst {
+		node = id & mask(s.NodeLen)
+		sequence = (id >> s.NodeLen) & mask(s.SequenceLen)
+	} else {
+		sequence = id & mask(s.SequenceLen)
+		node = (id >> s.SequenceLen) & mask(s.NodeLen)
+	}
+	ticks := (id >> (s.NodeLen + s.SequenceLen)) & mask(s.TimestampLen)
+
+	return Components{
+		Time:     s.Epoch.Add(time.Duration(ticks) * s.TimeUnit),
+		Node:     node,
+		Sequence: sequence,
+	}
+}
+
+// Packs components into an ID under the scheme. Times are truncated to the scheme's time unit.
+func (s Scheme) Compose(c Components) (uint64, error) {
+	if c.Time.Before(s.Epoch) {
+		return 0, fmt.Errorf("%w: %s is before the %s epoch", ErrBeforeEpoch, c.Time.Format(time.RFC3339Nano), s.Name)
+	}
+
+	ticks := uint64(c.Time.Sub(s.Epoch) / s.TimeUnit)
+	if ticks > mask(s.TimestampLen) {
+		return 0, fmt.Errorf(
+			"%w: timestamp %d does not fit in %d bits", snowball.ErrSectionOverflow, ticks, s.TimestampLen,
+		)
+	}
+	if c.Node > mask(s.NodeLen) {
+		return 0, fmt.Errorf("%w: node %d does not fit in %d bits", snowball.ErrSectionOverflow, c.Node, s.NodeLen)
+	}
+	if c.Sequence > mask(s.SequenceLen) {
+		return 0, fmt.Errorf(
+			"%w: sequence %d does not fit in %d bits", snowball.ErrSectionOverflow, c.Sequence, s.SequenceLen,
+		)
+	}
+
+	id := ticks << (s.NodeLen + s.SequenceLen)
+	if s.SequenceFirst {
+		id |= c.Sequence<<s.NodeLen | c.Node
+	} else {
+		id |= c.Node<<s.SequenceLen | c.Sequence
+	}
+	return id, nil
+}
+
+// Converts an ID generated under the scheme into a Snowball ID with the given layout and epoch.
+func (s Scheme) ToSnowball(id uint64, layout snowball.Layout, epoch time.Time) (snowball.SnowballID, error) {
+	result, err := ToSnowball(s.Decompose(id), layout, epoch)
+	if err != nil {
+		return 0, fmt.Errorf("%s ID %d: %w", s.Name, id, err)
+	}
+
+	return result, nil
+}
+
+// Converts a Snowball ID with the given layout and epoch into an ID under the scheme.
+func (s Scheme) FromSnowball(id snowball.SnowballID, layout snowball.Layout, epoch time.Time) (uint64, error) {
+	result, err := s.Compose(FromSnowball(id, layout, epoch))
+	if err != nil {
+		return 0, fmt.Errorf("snowball ID %d: %w", id, err)
+	}
+
+	return result, nil
+}
+
+// Packs components into a Snowball ID with the given layout and epoch. The node becomes the server ID.
+func ToSnowball(c Components, layout snowball.Layout, epoch time.Time) (snowball.SnowballID, error) {
+	if c.Time.Before(epoch) {
+		return 0, fmt.Errorf(
+			"%w: %s is before %s", ErrBeforeEpoch, c.Time.Format(time.RFC3339Nano), epoch.Format(time.RFC3339Nano),
+		)
+	}
+
+	return layout.Compose(uint64(c.Time.Sub(epoch).Milliseconds()), c.Node, c.Sequence)
+}
+
+// Unpacks a Snowball ID with the given layout and epoch into its components.
+func FromSnowball(id snowball.SnowballID, layout snowball.Layout, epoch time.Time) Components {
+	timestamp, serverId, sequence := layout.Decompose(id)
+	return Components{
+		Time:     epoch.Add(time.Duration(timestamp) * time.Millisecond),
+		Node:     serverId,
+		Sequence: sequence,
+	}
+}
+
+func mask(bits uint8) uint64 {
+	return 1<<bits - 1
+}
@@ -0,0 +1,144 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+var snowballEpoch = time.UnixMilli(1288834974657)
+
+func TestDecompose(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme Scheme
+		arg    uint64
+		want   Components
+	}{
+		{
+			// Example from the Discord API reference.
+			name:   "Discord",
+			scheme: Discord,
+			arg:    175928847299117063,
+			want: Components{
+				Time:     time.Date(2016, time.April, 30, 11, 18, 25, 796000000, time.UTC),
+				Node:     1 << 5,
+				Sequence: 7,
+			},
+		},
+		{
+			name:   "Twitter",
+			scheme: Twitter,
+			arg:    1<<22 | 3<<12 | 4095,
+			want: Components{
+				Time:     time.UnixMilli(1288834974658),
+				Node:     3,
+				Sequence: 4095,
+			},
+		},
+		{
+			name:   "Sonyflake",
+			scheme: Sonyflake,
+			arg:    100<<24 | 5<<16 | 65535,
+			want: Components{
+				Time:     time.Date(2014, time.September, 1, 0, 0, 1, 0, time.UTC),
+				Node:     65535,
+				Sequence: 5,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.scheme.Decompose(tt.arg)
+			if !got.Time.Equal(tt.want.Time) || got.Node != tt.want.Node || got.Sequence != tt.want.Sequence {
+				t.Errorf("Decompose() decoded: %+v, want: %+v", got, tt.want)
+			}
+
+			id, err := tt.scheme.Compose(got)
+			if err != nil || id != tt.arg {
+				t.Errorf("Compose() encoded: %v, want: %v (error: %v)", id, tt.arg, err)
+			}
+		})
+	}
+}
+
+func TestToSnowball(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  Scheme
+		arg     uint64
+		want    snowball.SnowballID
+		wantErr error
+	}{
+		{
+			name:   "Twitter, same epoch",
+			scheme: Twitter,
+			arg:    1000<<22 | 32<<12 | 17,
+			want:   1000<<22 | 32<<11 | 17,
+		},
+		{
+			name:    "Twitter, sequence too wide",
+			scheme:  Twitter,
+			arg:     1000<<22 | 32<<12 | 2048,
+			wantErr: snowball.ErrSectionOverflow,
+		},
+		{
+			name:   "Discord, later epoch",
+			scheme: Discord,
+			arg:    1<<22 | 1<<12 | 1,
+			want:   (1420070400000-1288834974657+1)<<22 | 1<<11 | 1,
+		},
+		{
+			name:    "Sonyflake, machine ID too wide",
+			scheme:  Sonyflake,
+			arg:     100<<24 | 5<<16 | 2048,
+			wantErr: snowball.ErrSectionOverflow,
+		},
+		{
+			name:   "Sonyflake, fits",
+			scheme: Sonyflake,
+			arg:    100<<24 | 5<<16 | 2047,
+			want:   (1409529600000-1288834974657+1000)<<22 | 2047<<11 | 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.scheme.ToSnowball(tt.arg, snowball.DefaultLayout, snowballEpoch)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ToSnowball() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ToSnowball() encoded: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToSnowballBeforeEpoch(t *testing.T) {
+	laterEpoch := time.UnixMilli(1704121810000)
+	_, err := Twitter.ToSnowball(1<<22, snowball.DefaultLayout, laterEpoch)
+	if !errors.Is(err, ErrBeforeEpoch) {
+		t.Errorf("ToSnowball() error: %v, wantErr: %v", err, ErrBeforeEpoch)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, scheme := range []Scheme{Twitter, Discord} {
+		t.Run(scheme.Name, func(t *testing.T) {
+			arg := uint64(123456789)<<22 | 1023<<12 | 2047
+			id, err := scheme.ToSnowball(arg, snowball.DefaultLayout, snowballEpoch)
+			if err != nil {
+				t.Fatalf("ToSnowball() error: %v", err)
+			}
+
+			back, err := scheme.FromSnowball(id, snowball.DefaultLayout, snowballEpoch)
+			if err != nil || back != arg {
+				t.Errorf("FromSnowball() decoded: %v, want: %v (error: %v)", back, arg, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/MrM21632/snowball/config"
+	"github.com/MrM21632/snowball/snowball"
+	"github.com/gin-gonic/gin"
+)
+
+// The service's default node along with the nodes of its namespaces, whose server IDs are derived from the
+// default node's. Suspending the group suspends every node, so losing the server ID lease or detecting a
+// collision stops all of them.
+type nodeGroup struct {
+	*snowball.SnowballNode
+	namespaces map[string]*snowball.SnowballNode
+}
+
+// Creates a node for each configured namespace, with the namespace's epoch and the default node's server ID
+// mapped into the namespace's block.
+func newNodeGroup(node *snowball.SnowballNode, cfg *config.Config, opts ...snowball.Option) (*nodeGroup, error) {
+	result := &nodeGroup{SnowballNode: node, namespaces: make(map[string]*snowball.SnowballNode)}
+	for _, name := range cfg.NamespaceNames() {
+		namespace := cfg.Namespaces[name]
+		serverId, err := namespace.ServerId(node.ServerId())
+		if err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", name, err)
+		}
+
+		nodeOpts := append(slices.Clip(opts),
+			snowball.WithNamespace(name),
+			snowball.WithEpoch(namespace.Epoch()),
+			snowball.WithServerIDProvider(snowball.StaticServerID(serverId)),
+		)
+		if result.namespaces[name], err = snowball.InitNode(false, nodeOpts...); err != nil {
+			return nil, fmt.Errorf("namespace %s: %w", name, err)
+		}
+	}
+	return result, nil
+}
+
+// Suspends every node in the group.
+func (g *nodeGroup) Suspend(reason error) (resume func()) {
+	resumes := []func(){g.SnowballNode.Suspend(reason)}
+	for _, node := range g.namespaces {
+		resumes = append(resumes, node.Suspend(reason))
+	}
+	return func() {
+		for _, resume := range resumes {
+			resume()
+		}
+	}
+}
+
+// Reports whether every node in the group is ready to issue IDs.
+func (g *nodeGroup) Ready() error {
+	if err := g.SnowballNode.Ready(); err != nil {
+		return err
+	}
+	for name, node := range g.namespaces {
+		if err := node.Ready(); err != nil {
+			return fmt.Errorf("namespace %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Handles requests for /generate/:namespace and /namespaces/:namespace routes by passing them to the handler
+// for the namespace's node. Responds with 404 for unknown namespaces.
+func namespaceHandler(
+	nodes map[string]*snowball.SnowballNode, handler func(*snowball.SnowballNode) gin.HandlerFunc,
+) gin.HandlerFunc {
+	handlers := make(map[string]gin.HandlerFunc, len(nodes))
+	for name, node := range nodes {
+		handlers[name] = handler(node)
+	}
+
+	return func(c *gin.Context) {
+		h, ok := handlers[c.Param("namespace")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("unknown namespace %q", c.Param("namespace"))})
+			return
+		}
+		h(c)
+	}
+}
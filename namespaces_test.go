@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MrM21632/snowball/config"
+	"github.com/MrM21632/snowball/snowball"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestNodeGroup(t *testing.T) *nodeGroup {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+	node, err := snowball.InitNode(false)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+
+	cfg := config.Default()
+	cfg.Namespaces = map[string]*config.NamespaceConfig{
+		"orders": {EpochMs: 1704067200000, ServerIdOffset: 1024, ServerIdRange: 1024},
+		"users":  {EpochMs: 1704121810000, ServerIdOffset: 1024},
+	}
+	nodes, err := newNodeGroup(node, cfg)
+	if err != nil {
+		t.Fatalf("Error occurred running newNodeGroup: %s", err)
+	}
+	return nodes
+}
+
+func TestNewNodeGroup(t *testing.T) {
+	nodes := newTestNodeGroup(t)
+
+	orders := nodes.namespaces["orders"]
+	if orders.ServerId() != 1056 || !orders.Epoch().Equal(time.UnixMilli(1704067200000)) {
+		t.Errorf("orders node: server ID %d, epoch %s, want: 1056, 2024-01-01", orders.ServerId(), orders.Epoch())
+	}
+	if users := nodes.namespaces["users"]; users.ServerId() != 1056 || users.Namespace() != "users" {
+		t.Errorf("users node: server ID %d, namespace %q, want: 1056, users", users.ServerId(), users.Namespace())
+	}
+
+	resume := nodes.Suspend(errors.New("lease lost"))
+	if _, err := orders.NextID(); !errors.Is(err, snowball.ErrSuspended) {
+		t.Errorf("orders NextID() error while group suspended: %v, want: %v", err, snowball.ErrSuspended)
+	}
+	if err := nodes.Ready(); !errors.Is(err, snowball.ErrSuspended) {
+		t.Errorf("Ready() while group suspended: %v, want: %v", err, snowball.ErrSuspended)
+	}
+	resume()
+	if err := nodes.Ready(); err != nil {
+		t.Errorf("Ready() after resuming: %v", err)
+	}
+
+	node, _ := snowball.InitNode(false, snowball.WithServerIDProvider(snowball.StaticServerID(1024)))
+	cfg := config.Default()
+	cfg.Namespaces = map[string]*config.NamespaceConfig{"orders": {EpochMs: 1704067200000, ServerIdRange: 1024}}
+	if _, err := newNodeGroup(node, cfg); err == nil {
+		t.Errorf("No error occurred running newNodeGroup with a server ID outside the namespace's range")
+	}
+}
+
+func TestNamespaceHandlers(t *testing.T) {
+	nodes := newTestNodeGroup(t)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/generate/:namespace", namespaceHandler(nodes.namespaces, func(node *snowball.SnowballNode) gin.HandlerFunc {
+		return generateHandler(node, 100)
+	}))
+	r.GET("/namespaces/:namespace/ids/:id", namespaceHandler(nodes.namespaces, inspectHandler))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/generate/orders", nil))
+	var generated struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &generated); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("POST /generate/orders: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/namespaces/orders/ids/"+generated.Id, nil))
+	var inspected struct {
+		Namespace string `json:"namespace"`
+		ServerId  uint64 `json:"server_id"`
+		Timestamp string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &inspected); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET /namespaces/orders/ids/%s: %d %s", generated.Id, w.Code, w.Body.String())
+	}
+	if inspected.Namespace != "orders" || inspected.ServerId != 1056 {
+		t.Errorf("GET /namespaces/orders/ids/%s body: %s", generated.Id, w.Body.String())
+	}
+	if ts, _ := time.Parse(time.RFC3339, inspected.Timestamp); time.Since(ts) > time.Minute {
+		t.Errorf("GET /namespaces/orders/ids/%s timestamp: %s, want about now", generated.Id, inspected.Timestamp)
+	}
+
+	for _, tt := range []struct{ method, path string }{
+		{http.MethodPost, "/generate/billing"},
+		{http.MethodGet, "/namespaces/billing/ids/42"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s %s status: %d, want: %d", tt.method, tt.path, w.Code, http.StatusNotFound)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Package peers detects server ID collisions between running nodes, which would otherwise silently produce
+// duplicate IDs, e.g. when two deployments are misconfigured with the same SNOWBALL_NODE_ID.
+//
+// Nodes periodically announce their server ID and epoch to a configured list of peers over HTTP, and each
+// peer answers with its own announcement, so a collision is noticed by both sides of any exchange. A node that
+// learns of another running instance with the same server ID suspends itself, which stops it generating IDs
+// and fails its readiness check, until the colliding instance is replaced or stops answering.
+//
+// Only announcements from configured peers are acted on: an incoming announcement must name, as its sender's URL,
+// one of the detector's Peers. If the detector has a Secret, announcements must also present it, so that clients
+// that can reach the API cannot suspend the node by announcing its server ID.
+package peers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Path that announcements are exchanged on, relative to a peer's base URL.
+const AnnouncePath = "/peers/announce"
+
+// Default time between rounds of announcements.
+const DefaultInterval = 10 * time.Second
+
+// Default number of consecutive rounds a colliding peer may fail to answer before its collision expires.
+const DefaultMaxMissed = 3
+
+var ErrServerIdCollision = errors.New("server ID collision")
+
+// What a node announces about itself to its peers.
+type Announcement struct {
+	ServerId uint64 `json:"server_id"`
+	// The node's epoch, in milliseconds since the Unix epoch.
+	EpochMs int64 `json:"epoch_ms"`
+	// Identifies the running process, so that a node can recognize its own announcements and tell a restarted
+	// peer apart from the instance it replaced.
+	Instance string `json:"instance"`
+	// Base URL the node is reachable at, as listed in its peers' Peers. Empty if the node does not know it.
+	URL string `json:"url,omitempty"`
+}
+
+// Implemented by *snowball.SnowballNode.
+type Node interface {
+	ServerId() uint64
+	Epoch() time.Time
+	Suspend(reason error) (resume func())
+}
+
+// Exchanges announcements with peers and suspends the node while a collision is known.
+//
+// Collisions are resolved once the peer answers as a different instance (i.e. the colliding process has been
+// replaced), or once it has failed to answer MaxMissed rounds in a row (e.g. because it was scaled down), at
+// which point the node resumes. At most one collision is kept per peer. A peer that is merely unreachable from
+// the node also has its collision expire, so the node may resume while the colliding instance still runs; it
+// is suspended again as soon as either side reaches the other.
+type Detector struct {
+	// Base URLs of the peers to announce to, e.g. "http://snowball-1.snowball:8080". Announcements received from
+	// other URLs are answered but otherwise ignored.
+	Peers []string
+	// Base URL of the node itself, as listed in its peers' Peers, sent with its announcements so peers can tell
+	// it is one of theirs. If empty, it is learned when the node finds itself among its own Peers.
+	URL string
+	// Shared secret announcements must carry, as a bearer token in the Authorization header. If empty,
+	// announcements are not authenticated.
+	Secret string
+	// Time between rounds of announcements; defaults to DefaultInterval.
+	Interval time.Duration
+	// Number of consecutive rounds a colliding peer may fail to answer before its collision expires; defaults to
+	// DefaultMaxMissed.
+	MaxMissed int
+	// Client used to send announcements; defaults to a client with a timeout of Interval.
+	Client *http.Client
+	// Logger for unreachable peers and collisions; defaults to snowball.Logger().
+	Logger *slog.Logger
+
+	node Node
+	self Announcement
+
+	mutex sync.Mutex
+	// Base URL of the node, once known.
+	url string
+	// Last instance seen at each peer URL.
+	instances map[string]string
+	// Number of consecutive rounds each peer URL has failed to answer.
+	missed map[string]int
+	// Colliding instances, with the functions resuming the node from each collision.
+	collisions map[string]func()
+}
+
+// Creates and returns a detector for the node, announcing to the given peers.
+func NewDetector(node Node, peers []string) *Detector {
+	var instance [8]byte
+	if _, err := rand.Read(instance[:]); err != nil {
+		panic(err)
+	}
+
+	return &Detector{
+		Peers: peers,
+		node:  node,
+		self: Announcement{
+			ServerId: node.ServerId(),
+			EpochMs:  node.Epoch().UnixMilli(),
+			Instance: hex.EncodeToString(instance[:]),
+		},
+		instances:  map[string]string{},
+		missed:     map[string]int{},
+		collisions: map[string]func(){},
+	}
+}
+
+// Returns the announcement the node sends to its peers.
+func (d *Detector) Self() Announcement {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	result := d.self
+	result.URL = d.url
+	if d.URL != "" {
+		result.URL = normalizeURL(d.URL)
+	}
+	return result
+}
+
+// Returns the instances currently known to collide with the node.
+func (d *Detector) Collisions() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	result := make([]string, 0, len(d.collisions))
+	for instance := range d.collisions {
+		result = append(result, instance)
+	}
+	return result
+}
+
+// Handles announcements from peers: records the peer's announcement and responds with the node's own.
+func (d *Detector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !d.authorized(r.Header.Get("Authorization")) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var peer Announcement
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&peer); err != nil {
+		http.Error(w, "invalid announcement: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if url := normalizeURL(peer.URL); url != "" && d.configured(url) {
+		d.observe(url, peer)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.Self())
+}
+
+// Reports whether an Authorization header carries the detector's secret, if it has one.
+func (d *Detector) authorized(header string) bool {
+	if d.Secret == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(d.Secret)) == 1
+}
+
+// Reports whether the URL is one of the detector's peers.
+func (d *Detector) configured(url string) bool {
+	return slices.ContainsFunc(d.Peers, func(peer string) bool { return normalizeURL(peer) == url })
+}
+
+func normalizeURL(url string) string {
+	return strings.TrimSuffix(url, "/")
+}
+
+// Announces the node to every peer immediately and then every Interval, until the context is done.
+func (d *Detector) Run(ctx context.Context) {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: interval}
+	}
+	maxMissed := d.MaxMissed
+	if maxMissed <= 0 {
+		maxMissed = DefaultMaxMissed
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, peer := range d.Peers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Unreachable peers are skipped; they will be retried next round.
+				if err := d.announce(ctx, client, peer); err != nil && ctx.Err() == nil {
+					d.log().Warn("peer announcement failed", slog.String("peer", peer), slog.Any("error", err))
+					d.miss(normalizeURL(peer), maxMissed)
+				}
+			}()
+		}
+		wg.Wait()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sends the node's announcement to a peer and records the peer's response.
+func (d *Detector) announce(ctx context.Context, client *http.Client, peer string) error {
+	body, err := json.Marshal(d.Self())
+	if err != nil {
+		return err
+	}
+
+	peer = normalizeURL(peer)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, peer+AnnouncePath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.Secret != "" {
+		req.Header.Set("Authorization", "Bearer "+d.Secret)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("announce to %s failed: %s", peer, resp.Status)
+	}
+
+	var result Announcement
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("announce to %s failed: %w", peer, err)
+	}
+	d.observe(peer, result)
+	return nil
+}
+
+// Records an announcement from the peer at the given URL, either its reply to the node's announcement or one it
+// sent itself.
+func (d *Detector) observe(peer string, a Announcement) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.missed, peer)
+	if a.Instance == d.self.Instance {
+		// The node is among its own peers, so now knows its URL.
+		if d.url == "" {
+			d.url = peer
+		}
+		return
+	}
+
+	// A different instance at the peer's URL means the previous one is no longer running there.
+	if previous, ok := d.instances[peer]; ok && previous != a.Instance {
+		if resume, ok := d.collisions[previous]; ok {
+			resume()
+			delete(d.collisions, previous)
+		}
+	}
+	d.instances[peer] = a.Instance
+
+	if a.ServerId != d.self.ServerId {
+		return
+	}
+	if _, ok := d.collisions[a.Instance]; ok {
+		return
+	}
+
+	other := "instance " + a.Instance + " at " + peer
+	if a.EpochMs != d.self.EpochMs {
+		other += fmt.Sprintf(" (with epoch %d)", a.EpochMs)
+	}
+	reason := fmt.Errorf("%w: server ID %d is also used by %s", ErrServerIdCollision, a.ServerId, other)
+	d.log().Error("server ID collision detected",
+		slog.Uint64("server_id", a.ServerId),
+		slog.String("instance", a.Instance),
+		slog.String("peer", peer),
+		slog.Int64("peer_epoch_ms", a.EpochMs),
+	)
+	d.collisions[a.Instance] = d.node.Suspend(reason)
+}
+
+// Records that the peer at the given URL failed to answer a round of announcements. Once it has missed
+// maxMissed rounds in a row, its instance is forgotten and any collision with it expires.
+func (d *Detector) miss(peer string, maxMissed int) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.missed[peer]++
+	instance, ok := d.instances[peer]
+	if !ok || d.missed[peer] < maxMissed {
+		return
+	}
+	delete(d.instances, peer)
+	if resume, ok := d.collisions[instance]; ok {
+		d.log().Warn("colliding peer stopped answering, resuming ID generation",
+			slog.String("instance", instance),
+			slog.String("peer", peer),
+			slog.Int("missed_rounds", d.missed[peer]),
+		)
+		resume()
+		delete(d.collisions, instance)
+	}
+}
+
+func (d *Detector) log() *slog.Logger {
+	if d.Logger != nil {
+		return d.Logger
+	}
+	return snowball.Logger()
+}
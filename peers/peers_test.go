@@ -0,0 +1,245 @@
+package peers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+func newTestNode(t *testing.T, serverId uint64) *snowball.SnowballNode {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+
+	node, err := snowball.InitNode(false, snowball.WithServerIDProvider(snowball.StaticServerID(serverId)))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	return node
+}
+
+// Starts an HTTP server whose detector can be replaced, simulating a peer being restarted at the same URL.
+func newTestPeer(t *testing.T, d *Detector) (*httptest.Server, *atomic.Pointer[Detector]) {
+	var current atomic.Pointer[Detector]
+	current.Store(d)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current.Load().ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &current
+}
+
+func TestDetector(t *testing.T) {
+	ctx := context.Background()
+	node := newTestNode(t, 32)
+	detector := NewDetector(node, nil)
+	detector.URL = "http://snowball-0.snowball:8080/"
+
+	distinct := NewDetector(newTestNode(t, 33), nil)
+	colliding := NewDetector(newTestNode(t, 32), []string{"http://snowball-0.snowball:8080"})
+	server, peer := newTestPeer(t, distinct)
+
+	// Nodes with distinct server IDs, and the node's own announcements, are not collisions.
+	if err := detector.announce(ctx, server.Client(), server.URL); err != nil {
+		t.Fatalf("announce() error: %v", err)
+	}
+	if err := distinct.announce(ctx, server.Client(), server.URL); err != nil {
+		t.Fatalf("announce() error: %v", err)
+	}
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() error: %v, want: nil", err)
+	}
+
+	// A collision suspends the nodes on both sides of the exchange.
+	peer.Store(colliding)
+	if err := detector.announce(ctx, server.Client(), server.URL); err != nil {
+		t.Fatalf("announce() error: %v", err)
+	}
+	if _, err := node.NextID(); !errors.Is(err, ErrServerIdCollision) {
+		t.Errorf("NextID() error: %v, want: %v", err, ErrServerIdCollision)
+	}
+	if len(colliding.Collisions()) != 1 || len(detector.Collisions()) != 1 {
+		t.Errorf("Collisions() = %v and %v, want one each", detector.Collisions(), colliding.Collisions())
+	}
+
+	// Once the colliding instance is replaced by one with another server ID, the node resumes.
+	peer.Store(NewDetector(newTestNode(t, 34), nil))
+	if err := detector.announce(ctx, server.Client(), server.URL); err != nil {
+		t.Fatalf("announce() error: %v", err)
+	}
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() error: %v, want: nil", err)
+	}
+}
+
+func TestDetectorUnconfiguredPeers(t *testing.T) {
+	node := newTestNode(t, 32)
+	detector := NewDetector(node, []string{"http://snowball-1.snowball:8080"})
+	announce := func(body string) {
+		w := httptest.NewRecorder()
+		detector.ServeHTTP(w, httptest.NewRequest(http.MethodPost, AnnouncePath, strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST %s status: %d, want: %d", AnnouncePath, w.Code, http.StatusOK)
+		}
+	}
+
+	// Announcements from senders that are not configured peers are answered but ignored.
+	for i := 0; i < 100; i++ {
+		announce(fmt.Sprintf(`{"server_id":32,"instance":"spoofed-%d"}`, i))
+		announce(fmt.Sprintf(`{"server_id":32,"instance":"spoofed-%d","url":"http://attacker:8080"}`, i))
+	}
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() after unconfigured announcements: %v, want: nil", err)
+	}
+
+	// A configured peer keeps at most one collision, however many instances claim its URL.
+	for i := 0; i < 100; i++ {
+		announce(fmt.Sprintf(`{"server_id":32,"instance":"peer-%d","url":"http://snowball-1.snowball:8080/"}`, i))
+	}
+	if collisions := detector.Collisions(); len(collisions) != 1 || collisions[0] != "peer-99" {
+		t.Errorf("Collisions() = %v, want: [peer-99]", collisions)
+	}
+}
+
+func TestDetectorSecret(t *testing.T) {
+	detector := NewDetector(newTestNode(t, 32), []string{"http://snowball-1.snowball:8080"})
+	detector.Secret = "s3cret"
+	body := `{"server_id":32,"instance":"a","url":"http://snowball-1.snowball:8080"}`
+
+	for _, tt := range []struct {
+		header   string
+		wantCode int
+	}{
+		{header: "", wantCode: http.StatusUnauthorized},
+		{header: "Bearer wrong", wantCode: http.StatusUnauthorized},
+		{header: "s3cret", wantCode: http.StatusUnauthorized},
+		{header: "Bearer s3cret", wantCode: http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodPost, AnnouncePath, strings.NewReader(body))
+		r.Header.Set("Authorization", tt.header)
+		w := httptest.NewRecorder()
+		detector.ServeHTTP(w, r)
+		if w.Code != tt.wantCode {
+			t.Errorf("POST %s with Authorization %q status: %d, want: %d", AnnouncePath, tt.header, w.Code, tt.wantCode)
+		}
+	}
+	if len(detector.Collisions()) != 1 {
+		t.Errorf("Collisions() = %v, want only the authorized announcement", detector.Collisions())
+	}
+
+	// Outgoing announcements carry the secret.
+	peer := NewDetector(newTestNode(t, 33), nil)
+	peer.Secret = "s3cret"
+	server, _ := newTestPeer(t, peer)
+	if err := detector.announce(context.Background(), server.Client(), server.URL); err != nil {
+		t.Errorf("announce() to a peer with the same secret error: %v", err)
+	}
+	peer.Secret = "other"
+	if err := detector.announce(context.Background(), server.Client(), server.URL); err == nil {
+		t.Errorf("No error occurred announcing to a peer with another secret")
+	}
+}
+
+func TestDetectorRun(t *testing.T) {
+	first := NewDetector(newTestNode(t, 7), nil)
+	second := NewDetector(newTestNode(t, 7), nil)
+	firstServer, _ := newTestPeer(t, first)
+	secondServer, _ := newTestPeer(t, second)
+	// first finds its own URL among its peers, and sends it to second, which only listens to first.
+	first.Peers = []string{firstServer.URL, secondServer.URL + "/"}
+	second.Peers = []string{firstServer.URL}
+	first.Interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		first.Run(ctx)
+		close(done)
+	}()
+
+	// Both sides record the collision: second when it receives the announcement, first when it reads the reply.
+	deadline := time.Now().Add(time.Second)
+	for ; len(first.Collisions()) == 0 || len(second.Collisions()) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for collision to be detected")
+		}
+	}
+	cancel()
+	<-done
+
+	if len(first.Collisions()) != 1 {
+		t.Errorf("Collisions() = %v, want only the second node", first.Collisions())
+	}
+	if url := first.Self().URL; url != firstServer.URL {
+		t.Errorf("Self().URL = %q, want: %q", url, firstServer.URL)
+	}
+}
+
+func TestDetectorExpiresCollisions(t *testing.T) {
+	node := newTestNode(t, 7)
+	detector := NewDetector(node, nil)
+	colliding := NewDetector(newTestNode(t, 7), nil)
+	server, _ := newTestPeer(t, colliding)
+	detector.Peers = []string{server.URL}
+	detector.Interval = 10 * time.Millisecond
+	detector.MaxMissed = 2
+
+	if err := detector.announce(context.Background(), server.Client(), server.URL); err != nil {
+		t.Fatalf("announce() error: %v", err)
+	}
+	if err := node.Ready(); !errors.Is(err, ErrServerIdCollision) {
+		t.Fatalf("Ready() error: %v, want: %v", err, ErrServerIdCollision)
+	}
+
+	// A single missed round is not enough, as the peer may just be slow.
+	detector.miss(server.URL, detector.MaxMissed)
+	if err := node.Ready(); !errors.Is(err, ErrServerIdCollision) {
+		t.Errorf("Ready() error after one missed round: %v, want: %v", err, ErrServerIdCollision)
+	}
+
+	// Once the colliding peer stops answering, e.g. because it was scaled down, the collision expires.
+	server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go detector.Run(ctx)
+	deadline := time.Now().Add(time.Second)
+	for ; len(detector.Collisions()) > 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for collision to expire")
+		}
+	}
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() error after the colliding peer stopped answering: %v, want: nil", err)
+	}
+}
+
+func TestDetectorServeHTTP(t *testing.T) {
+	detector := NewDetector(newTestNode(t, 32), nil)
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		wantCode int
+	}{
+		{name: "Announcement", method: http.MethodPost, body: `{"server_id":1,"instance":"a"}`, wantCode: http.StatusOK},
+		{name: "Invalid body", method: http.MethodPost, body: `{"server_id":`, wantCode: http.StatusBadRequest},
+		{name: "Wrong method", method: http.MethodGet, wantCode: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			detector.ServeHTTP(w, httptest.NewRequest(tt.method, AnnouncePath, strings.NewReader(tt.body)))
+			if w.Code != tt.wantCode {
+				t.Errorf("%s %s status: %d, want: %d", tt.method, AnnouncePath, w.Code, tt.wantCode)
+			}
+		})
+	}
+}
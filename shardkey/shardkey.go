@@ -0,0 +1,59 @@
+// Package shardkey maps Snowball IDs onto shards, e.g. Kafka partitions or database shards, for partitioning
+// data by ID.
+//
+// Taking an ID modulo the number of shards distributes IDs poorly: the low bits of an ID are its sequence, which
+// starts at 0 in every millisecond, so when few IDs are issued per millisecond most of them land on the first few
+// shards. Instead, ShardOf hashes the ID with a stable hash before reducing it to a shard, which spreads IDs
+// evenly whatever their sequence.
+//
+// ShardOf moves almost every ID to a different shard when the number of shards changes. Where data has to be
+// moved when resharding, use JumpShardOf, a jump consistent hash: growing from n to n+1 shards only moves about
+// 1/(n+1) of the IDs, all of them onto the new shard.
+//
+// Both functions are stable: the same ID and number of shards always give the same shard, across processes and
+// versions of this package.
+package shardkey
+
+import (
+	"math/bits"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+// Returns a stable 64-bit hash of the ID, in which every bit depends on every bit of the ID. This is the
+// finalizer of MurmurHash3, and must never change, as shards are assigned from it.
+func Hash(id snowball.SnowballID) uint64 {
+	h := uint64(id)
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// Returns the shard in 0..n-1 the ID belongs to. Panics if n is not positive.
+func ShardOf(id snowball.SnowballID, n int) int {
+	if n <= 0 {
+		panic("shardkey: number of shards must be positive")
+	}
+	// Scale the hash onto 0..n-1 rather than taking it modulo n, which would favor the lower shards slightly.
+	shard, _ := bits.Mul64(Hash(id), uint64(n))
+	return int(shard)
+}
+
+// Returns the shard in 0..n-1 the ID belongs to, using the jump consistent hash of Lamping and Veach, so that
+// changing the number of shards moves as few IDs as possible. Panics if n is not positive.
+func JumpShardOf(id snowball.SnowballID, n int) int {
+	if n <= 0 {
+		panic("shardkey: number of shards must be positive")
+	}
+	key := Hash(id)
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
@@ -0,0 +1,150 @@
+package shardkey
+
+import (
+	"testing"
+
+	"github.com/MrM21632/snowball/snowball"
+)
+
+func TestShardOf(t *testing.T) {
+	tests := []struct {
+		name string
+		id   snowball.SnowballID
+		n    int
+		want int
+		jump int
+	}{
+		{name: "Single shard", id: 19638199173316608, n: 1, want: 0, jump: 0},
+		{name: "16 shards", id: 19638199173316608, n: 16, want: 2, jump: 10},
+		{name: "1000 shards", id: 2112208322743511841, n: 1000, want: 523, jump: 374},
+		{name: "Sequence 1", id: 1, n: 16, want: 11, jump: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShardOf(tt.id, tt.n); got != tt.want {
+				t.Errorf("ShardOf(%d, %d) = %d, want: %d", tt.id, tt.n, got, tt.want)
+			}
+			if got := JumpShardOf(tt.id, tt.n); got != tt.jump {
+				t.Errorf("JumpShardOf(%d, %d) = %d, want: %d", tt.id, tt.n, got, tt.jump)
+			}
+		})
+	}
+}
+
+// Returns IDs as issued by a fleet of 8 nodes under light load: a few IDs per node per millisecond, so nearly
+// all of them have a sequence of 0, 1 or 2.
+func lowVolumeIDs(t *testing.T) []snowball.SnowballID {
+	var result []snowball.SnowballID
+	for timestamp := uint64(4682111543); len(result) < 100000; timestamp++ {
+		for serverId := uint64(0); serverId < 8; serverId++ {
+			for sequence := uint64(0); sequence < timestamp%3+1; sequence++ {
+				id, err := snowball.DefaultLayout.Compose(timestamp, serverId, sequence)
+				if err != nil {
+					t.Fatalf("Error occurred running Compose: %s", err)
+				}
+				result = append(result, id)
+			}
+		}
+	}
+	return result
+}
+
+// Returns IDs issued by a single node as fast as it can, exhausting the sequence every millisecond.
+func highVolumeIDs(t *testing.T) []snowball.SnowballID {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+	node, err := snowball.InitNode(false)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	return node.GenerateIDs(100000)
+}
+
+// Checks that every shard received within 15% of its fair share of the IDs.
+func checkDistribution(t *testing.T, ids []snowball.SnowballID, n int, shardOf func(snowball.SnowballID, int) int) {
+	t.Helper()
+	counts := make([]int, n)
+	for _, id := range ids {
+		counts[shardOf(id, n)]++
+	}
+	want := float64(len(ids)) / float64(n)
+	for shard, count := range counts {
+		if float64(count) < want*0.85 || float64(count) > want*1.15 {
+			t.Errorf("Shard %d of %d received %d of %d IDs, want about %.0f", shard, n, count, len(ids), want)
+		}
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	lowVolume := lowVolumeIDs(t)
+
+	// The test data must show the problem ShardOf solves: modulo 16, almost every ID lands on shards 0 to 2.
+	clustered := 0
+	for _, id := range lowVolume {
+		if id%16 <= 2 {
+			clustered++
+		}
+	}
+	if clustered != len(lowVolume) {
+		t.Fatalf("%d of %d low-volume IDs have id %% 16 <= 2, want all of them", clustered, len(lowVolume))
+	}
+
+	highVolume := highVolumeIDs(t)
+	for _, n := range []int{3, 16, 64, 100} {
+		checkDistribution(t, lowVolume, n, ShardOf)
+		checkDistribution(t, lowVolume, n, JumpShardOf)
+		checkDistribution(t, highVolume, n, ShardOf)
+		checkDistribution(t, highVolume, n, JumpShardOf)
+	}
+}
+
+func TestJumpShardOfResharding(t *testing.T) {
+	ids := highVolumeIDs(t)
+	for _, n := range []int{1, 7, 16, 99} {
+		moved := 0
+		for _, id := range ids {
+			before, after := JumpShardOf(id, n), JumpShardOf(id, n+1)
+			if before != after {
+				if after != n {
+					t.Fatalf("ID %d moved from shard %d to %d when growing to %d shards, want: %d",
+						id, before, after, n+1, n)
+				}
+				moved++
+			}
+		}
+
+		// About 1/(n+1) of the IDs should move to the new shard.
+		want := float64(len(ids)) / float64(n+1)
+		if float64(moved) < want*0.9 || float64(moved) > want*1.1 {
+			t.Errorf("Growing from %d to %d shards moved %d of %d IDs, want about %.0f", n, n+1, moved, len(ids), want)
+		}
+	}
+}
+
+func TestShardOfPanics(t *testing.T) {
+	for name, shardOf := range map[string]func(snowball.SnowballID, int) int{
+		"ShardOf": ShardOf, "JumpShardOf": JumpShardOf,
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s(id, 0) did not panic", name)
+				}
+			}()
+			shardOf(1, 0)
+		}()
+	}
+}
+
+func BenchmarkShardOf(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		_ = ShardOf(snowball.SnowballID(n), 64)
+	}
+}
+
+func BenchmarkJumpShardOf(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		_ = JumpShardOf(snowball.SnowballID(n), 64)
+	}
+}
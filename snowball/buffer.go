@@ -0,0 +1,161 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrBufferClosed = errors.New("buffer closed")
+
+// Default number of IDs held by a Buffer.
+const DefaultBufferSize = 4096
+
+// Serves IDs pre-generated by a node from a lock-free ring buffer, which a background goroutine tops up whenever
+// it falls to the low-water mark. Callers never wait on the node's lock or for sequence exhaustion unless the
+// buffer runs dry, in which case the ID is generated directly and counted as an underflow in the node's metrics.
+//
+// Buffered IDs are generated ahead of time, so their timestamps lag the time they are handed out by however long
+// they sat in the buffer: up to the time taken to consume size-lowWater IDs after a refill, and without bound if
+// the buffer sits idle. Don't use a Buffer where an ID's timestamp must reflect when it was requested. IDs from a
+// Buffer are unique, but are only increasing for a single caller while the buffer is not underflowing. IDs still
+// buffered when the Buffer is closed are never issued.
+type Buffer struct {
+	node     *SnowballNode
+	lowWater uint64
+
+	// The ring. head is the position of the next ID to be taken and tail the position after the last ID added;
+	// both only ever increase, and slots[i % len(slots)] holds the ID at position i. IDs are only added by the
+	// refill goroutine, so only taking IDs needs to compete for head.
+	slots []atomic.Uint64
+	head  atomic.Uint64
+	tail  atomic.Uint64
+
+	refill    chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// Creates and returns a buffer of size IDs from the node, and starts refilling it in the background whenever
+// lowWater or fewer IDs remain. lowWater must be less than size. Call Close to stop the refill goroutine.
+func NewBuffer(node *SnowballNode, size, lowWater int) (*Buffer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid buffer: size must be positive, got %d", size)
+	}
+	if lowWater < 0 || lowWater >= size {
+		return nil, fmt.Errorf("invalid buffer: low-water mark must be between 0 and %d, got %d", size-1, lowWater)
+	}
+
+	result := &Buffer{
+		node:     node,
+		lowWater: uint64(lowWater),
+		slots:    make([]atomic.Uint64, size),
+		refill:   make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	result.fill()
+	go result.run()
+	return result, nil
+}
+
+// Creates and returns a new, unique Snowball ID, taken from the buffer if it is not empty. Returns an error
+// wrapping ErrSuspended if the node is suspended, or ErrBufferClosed if the buffer has been closed.
+func (b *Buffer) NextID() (SnowballID, error) {
+	select {
+	case <-b.done:
+		return 0, ErrBufferClosed
+	default:
+	}
+	// IDs buffered before a suspension are withheld until the node resumes, like IDs generated directly.
+	if err := b.node.suspended(); err != nil {
+		return 0, err
+	}
+
+	for {
+		head, tail := b.head.Load(), b.tail.Load()
+		if head == tail {
+			break
+		}
+		// The refill goroutine cannot overwrite this slot until head moves past it, so if the CAS succeeds the ID
+		// read is the one at position head.
+		id := b.slots[head%uint64(len(b.slots))].Load()
+		if b.head.CompareAndSwap(head, head+1) {
+			if tail-head-1 <= b.lowWater {
+				b.wake()
+			}
+			return SnowballID(id), nil
+		}
+	}
+
+	b.wake()
+	if b.node.metrics != nil {
+		b.node.metrics.bufferUnderflows.Inc()
+	}
+	return b.node.NextID()
+}
+
+// Creates and returns a new, unique Snowball ID. Returns 0 if the node is suspended or the buffer has been
+// closed; use NextID to tell this apart from a valid ID.
+func (b *Buffer) GenerateID() SnowballID {
+	id, _ := b.NextID()
+	return id
+}
+
+// Returns the number of IDs currently in the buffer.
+func (b *Buffer) Len() int {
+	return int(b.tail.Load() - b.head.Load())
+}
+
+// Stops the refill goroutine and waits for it to exit. Afterwards NextID returns ErrBufferClosed and the IDs left
+// in the buffer are discarded. Calling Close more than once has no further effect.
+func (b *Buffer) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+	<-b.stopped
+	return nil
+}
+
+// Asks the refill goroutine to top up the buffer, unless it has already been asked.
+func (b *Buffer) wake() {
+	select {
+	case b.refill <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Buffer) run() {
+	defer close(b.stopped)
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.refill:
+			b.fill()
+		}
+	}
+}
+
+// Tops up the buffer. Must only be called by one goroutine at a time.
+func (b *Buffer) fill() {
+	tail := b.tail.Load()
+	free := len(b.slots) - int(tail-b.head.Load())
+	if free <= 0 {
+		return
+	}
+
+	ids, err := b.node.NextIDs(free)
+	if err != nil {
+		// Most likely suspended; the next caller to find the buffer low will try again.
+		b.node.log().Debug("buffer refill failed", slog.Any("error", err))
+		return
+	}
+	for i, id := range ids {
+		b.slots[(tail+uint64(i))%uint64(len(b.slots))].Store(uint64(id))
+	}
+	b.tail.Store(tail + uint64(len(ids)))
+}
@@ -0,0 +1,153 @@
+package snowball
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewBuffer(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	tests := []struct {
+		name     string
+		size     int
+		lowWater int
+		wantErr  bool
+	}{
+		{name: "Valid buffer", size: 16, lowWater: 4},
+		{name: "Zero low-water mark", size: 16, lowWater: 0},
+		{name: "Zero size", size: 0, lowWater: 0, wantErr: true},
+		{name: "Negative low-water mark", size: 16, lowWater: -1, wantErr: true},
+		{name: "Low-water mark too high", size: 16, lowWater: 16, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer, err := NewBuffer(node, tt.size, tt.lowWater)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewBuffer() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer buffer.Close()
+			if buffer.Len() != tt.size {
+				t.Errorf("Len() of a new buffer: %d, want: %d", buffer.Len(), tt.size)
+			}
+		})
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	buffer, err := NewBuffer(node, 64, 16)
+	if err != nil {
+		t.Fatalf("Error occurred running NewBuffer: %s", err)
+	}
+
+	results := make([][]SnowballID, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				id, err := buffer.NextID()
+				if err != nil {
+					t.Errorf("NextID() error: %v", err)
+					return
+				}
+				results[i] = append(results[i], id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[SnowballID]bool)
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("ID %d was issued more than once", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != 8*10000 {
+		t.Errorf("Generated %d unique IDs, want: %d", len(seen), 8*10000)
+	}
+
+	resume := node.Suspend(nil)
+	if _, err := buffer.NextID(); !errors.Is(err, ErrSuspended) {
+		t.Errorf("NextID() error while suspended: %v, want: %v", err, ErrSuspended)
+	}
+	resume()
+
+	if err := buffer.Close(); err != nil {
+		t.Errorf("Close() error: %v", err)
+	}
+	if err := buffer.Close(); err != nil {
+		t.Errorf("Second Close() error: %v", err)
+	}
+	if _, err := buffer.NextID(); !errors.Is(err, ErrBufferClosed) {
+		t.Errorf("NextID() error after Close: %v, want: %v", err, ErrBufferClosed)
+	}
+}
+
+func TestBufferRefill(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	m := NewMetrics()
+	node, _ := InitNode(false, WithMetrics(m))
+	buffer, _ := NewBuffer(node, 8, 2)
+	defer buffer.Close()
+
+	for i := 0; i < 6; i++ {
+		buffer.GenerateID()
+	}
+	deadline := time.Now().Add(time.Second)
+	for buffer.Len() != 8 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if buffer.Len() != 8 {
+		t.Errorf("Len() after falling to the low-water mark: %d, want: 8", buffer.Len())
+	}
+
+	// Empty the buffer while the node is suspended, so it cannot be refilled in the meantime.
+	resume := node.Suspend(nil)
+	for i := 0; i < 8; i++ {
+		buffer.head.Add(1)
+	}
+	resume()
+	if _, err := buffer.NextID(); err != nil {
+		t.Errorf("NextID() error from an empty buffer: %v", err)
+	}
+	metrics := gatherMetrics(t, m)
+	if got := metrics["snowball_buffer_underflows_total"].GetCounter().GetValue(); got != 1 {
+		t.Errorf("snowball_buffer_underflows_total: %v, want: 1", got)
+	}
+}
+
+func BenchmarkBufferGenerateID(b *testing.B) {
+	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	b.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	buffer, _ := NewBuffer(node, DefaultBufferSize, DefaultBufferSize/2)
+	defer buffer.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = buffer.GenerateID()
+		}
+	})
+}
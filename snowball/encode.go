@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"math"
 	"strconv"
 	"strings"
@@ -47,6 +48,9 @@ func FromBase32(sid string) (SnowballID, error) {
 	if err != nil {
 		return 0, errors.New("decode failed: invalid base32 string (did you use the right encoding?)")
 	}
+	if len(bytes) != 8 {
+		return 0, fmt.Errorf("decode failed: base32 string must decode to 8 bytes, got %d", len(bytes))
+	}
 
 	return SnowballID(binary.NativeEndian.Uint64(bytes)), nil
 }
@@ -65,6 +69,9 @@ func FromBase64(sid string) (SnowballID, error) {
 	if err != nil {
 		return 0, errors.New("decode failed: invalid base64 string (did you use the right encoding?)")
 	}
+	if len(bytes) != 8 {
+		return 0, fmt.Errorf("decode failed: base64 string must decode to 8 bytes, got %d", len(bytes))
+	}
 
 	return SnowballID(binary.NativeEndian.Uint64(bytes)), nil
 }
@@ -84,15 +91,101 @@ func (id SnowballID) ToBase62() string {
 // Converts a base62 string into a Snowball ID.
 func FromBase62(sid string) (SnowballID, error) {
 	var result uint64
-	for index, char := range sid {
-		pow := len(sid) - (index + 1)
+	for _, char := range sid {
 		pos := strings.IndexRune(base62Digits, char)
 		if pos == -1 {
 			return 0, errors.New("decode failed: invalid base62 string")
 		}
+		if result > (math.MaxUint64-uint64(pos))/62 {
+			return 0, errors.New("decode failed: base62 string exceeds 64 bits")
+		}
 
-		result += uint64(pos) * uint64(math.Pow(62, float64(pow)))
+		result = result*62 + uint64(pos)
 	}
 
 	return SnowballID(result), nil
 }
+
+// Names one of the string formats Snowball IDs can be encoded in.
+type Encoding string
+
+const (
+	EncodingDecimal Encoding = "decimal"
+	EncodingBinary  Encoding = "binary"
+	EncodingHex     Encoding = "hex"
+	EncodingBase32  Encoding = "base32"
+	EncodingBase62  Encoding = "base62"
+	EncodingBase64  Encoding = "base64"
+	EncodingUUID    Encoding = "uuid"
+)
+
+// All supported encodings.
+var Encodings = []Encoding{
+	EncodingDecimal, EncodingBinary, EncodingHex, EncodingBase32, EncodingBase62, EncodingBase64, EncodingUUID,
+}
+
+var ErrUnknownEncoding = errors.New("unknown encoding")
+
+// Converts an encoding name into an Encoding. Names are case-insensitive, and "base10" and "base16" are
+// accepted as aliases for decimal and hex respectively.
+func ParseEncoding(name string) (Encoding, error) {
+	switch enc := Encoding(strings.ToLower(name)); enc {
+	case "base10":
+		return EncodingDecimal, nil
+	case "base16":
+		return EncodingHex, nil
+	case EncodingDecimal, EncodingBinary, EncodingHex, EncodingBase32, EncodingBase62, EncodingBase64, EncodingUUID:
+		return enc, nil
+	}
+
+	names := make([]string, len(Encodings))
+	for i, enc := range Encodings {
+		names[i] = string(enc)
+	}
+	return "", fmt.Errorf("%w %q (supported encodings: %s)", ErrUnknownEncoding, name, strings.Join(names, ", "))
+}
+
+// Formats the Snowball ID using the given encoding.
+func (id SnowballID) Encode(enc Encoding) (string, error) {
+	switch enc {
+	case EncodingDecimal:
+		return strconv.FormatUint(uint64(id), 10), nil
+	case EncodingBinary:
+		return id.ToBinary(), nil
+	case EncodingHex:
+		return id.ToHex(), nil
+	case EncodingBase32:
+		return id.ToBase32(), nil
+	case EncodingBase62:
+		return id.ToBase62(), nil
+	case EncodingBase64:
+		return id.ToBase64(), nil
+	case EncodingUUID:
+		return id.ToUUIDString(), nil
+	}
+
+	return "", fmt.Errorf("%w %q", ErrUnknownEncoding, enc)
+}
+
+// Converts a string in the given encoding into a Snowball ID.
+func Decode(enc Encoding, sid string) (SnowballID, error) {
+	switch enc {
+	case EncodingDecimal:
+		id, err := strconv.ParseUint(sid, 10, 64)
+		return SnowballID(id), err
+	case EncodingBinary:
+		return FromBinary(sid)
+	case EncodingHex:
+		return FromHex(sid)
+	case EncodingBase32:
+		return FromBase32(sid)
+	case EncodingBase62:
+		return FromBase62(sid)
+	case EncodingBase64:
+		return FromBase64(sid)
+	case EncodingUUID:
+		return FromUUIDString(sid)
+	}
+
+	return 0, fmt.Errorf("%w %q", ErrUnknownEncoding, enc)
+}
@@ -1,6 +1,9 @@
 package snowball
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestBinaryEncode(t *testing.T) {
 	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
@@ -248,6 +251,24 @@ func TestBase32Decode(t *testing.T) {
 			want:    0,
 			wantErr: true,
 		},
+		{
+			name:    "Too short for an ID",
+			arg:     "AAAAAAAA",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Padded but too short for an ID",
+			arg:     "AAAAAAAAAAAA====",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Too long for an ID",
+			arg:     "AAAAAAAAAAAAAAAA",
+			want:    0,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -333,6 +354,24 @@ func TestBase62Decode(t *testing.T) {
 			want:    0,
 			wantErr: true,
 		},
+		{
+			name:    "Maximum ID",
+			arg:     "LygHa16AHYF",
+			want:    18446744073709551615,
+			wantErr: false,
+		},
+		{
+			name:    "Exceeds 64 bits",
+			arg:     "LygHa16AHYG",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Far exceeds 64 bits",
+			arg:     "zzzzzzzzzzzzzzzzzzzzzzzzzz",
+			want:    0,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -418,6 +457,24 @@ func TestBase64Decode(t *testing.T) {
 			want:    0,
 			wantErr: true,
 		},
+		{
+			name:    "Too short for an ID",
+			arg:     "AAAA",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Padded but too short for an ID",
+			arg:     "AAAAAAAAAA==",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Too long for an ID",
+			arg:     "AAAAAAAAAAAA",
+			want:    0,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -433,6 +490,61 @@ func TestBase64Decode(t *testing.T) {
 	}
 }
 
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    Encoding
+		wantErr bool
+	}{
+		{name: "Hex", arg: "hex", want: EncodingHex},
+		{name: "Upper case", arg: "BASE62", want: EncodingBase62},
+		{name: "Base16 alias", arg: "base16", want: EncodingHex},
+		{name: "Base10 alias", arg: "base10", want: EncodingDecimal},
+		{name: "UUID", arg: "uuid", want: EncodingUUID},
+		{name: "Unknown encoding", arg: "base58", want: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := ParseEncoding(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseEncoding() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnknownEncoding) {
+				t.Errorf("ParseEncoding() error: %v, want ErrUnknownEncoding", err)
+			}
+			if enc != tt.want {
+				t.Errorf("ParseEncoding() parsed: %v, want: %v", enc, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	var id SnowballID = 19638199173316608
+	for _, enc := range Encodings {
+		t.Run(string(enc), func(t *testing.T) {
+			encoded, err := id.Encode(enc)
+			if err != nil {
+				t.Fatalf("Encode() error: %v", err)
+			}
+
+			decoded, err := Decode(enc, encoded)
+			if err != nil || decoded != id {
+				t.Errorf("Decode() decoded: %v, want: %v (error: %v)", decoded, id, err)
+			}
+		})
+	}
+
+	if _, err := id.Encode("base58"); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("Encode() error: %v, want ErrUnknownEncoding", err)
+	}
+	if _, err := Decode("base58", "1"); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("Decode() error: %v, want ErrUnknownEncoding", err)
+	}
+}
+
 func BenchmarkToBinary(b *testing.B) {
 	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
 	b.Setenv("SNOWBALL_NODE_ID", "32")
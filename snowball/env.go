@@ -3,13 +3,18 @@ package snowball
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"os"
 	"strconv"
+	"time"
 )
 
 var ErrEmptyEnvVar = errors.New("getenv: Specified environment variable is empty or undefined")
 
+// The epoch used if none is configured: that of Twitter's Snowflake, 2010-11-04T01:42:54.657Z.
+const DefaultEpochMs uint64 = 1288834974657
+
 func GetenvStr(key string) (string, error) {
 	result := os.Getenv(key)
 	if result == "" {
@@ -47,48 +52,160 @@ func GetenvBoolean(key string) (bool, error) {
 	return result, nil
 }
 
+func GetenvDuration(key string) (time.Duration, error) {
+	str, err := GetenvStr(key)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
 // The epoch used by Snowball is entirely configurable via the SNOWBALL_EPOCH_MS system environment
 // variable. This makes using Snowball in containerized deployments straightforward - just supply
 // the expected environment variable and Snowball will take it from there.
 //
-// If the epoch is not provided, Snowball defaults to the epoch used by the original Snowflake algorithm.
+// The epoch may be given as milliseconds since the Unix epoch, an RFC 3339 timestamp or a date; see ParseEpoch.
+// If it is not provided, is malformed or is implausible (see ParseEpoch and CheckEpoch), Snowball logs a warning
+// and defaults to the epoch used by the original Snowflake algorithm. InitNode refuses implausible epochs instead,
+// since switching epochs would break the ordering of IDs already issued. Use LookupEpoch to detect these cases.
 func GetEpoch() uint64 {
-	var epoch uint64
-	var err error
-	if epoch, err = GetenvInteger("SNOWBALL_EPOCH_MS"); err != nil {
-		fmt.Println("get epoch failed: envvar SNOWBALL_EPOCH_MS not found, using default")
-		return 1288834974657
+	epoch, err := LookupEpoch()
+	if err != nil {
+		logFallback("SNOWBALL_EPOCH_MS", err, "epoch_ms", DefaultEpochMs)
+		return DefaultEpochMs
 	}
 
 	return epoch
 }
 
+// Reads the epoch from the SNOWBALL_EPOCH_MS environment variable, in any format accepted by ParseEpoch. Unlike
+// GetEpoch, returns an error naming the variable if it is not set (wrapping ErrEmptyEnvVar), is malformed or
+// fails CheckEpoch, rather than using the default.
+func LookupEpoch() (uint64, error) {
+	str, err := GetenvStr("SNOWBALL_EPOCH_MS")
+	if err != nil {
+		return 0, fmt.Errorf("SNOWBALL_EPOCH_MS is not set: %w", err)
+	}
+
+	epoch, err := ParseEpoch(str)
+	if err == nil {
+		err = CheckEpoch(epoch, time.Now())
+	}
+	if err != nil {
+		return 0, fmt.Errorf("SNOWBALL_EPOCH_MS is invalid: %w", err)
+	}
+
+	return uint64(epoch.UnixMilli()), nil
+}
+
 // The server ID used by Snowball is entirely configurable via the SNOWBALL_NODE_ID system environment
 // variable. This makes using Snowball in containerized deployments straightforward - just supply the
 // expected environment variable and Snowball will take it from there.
 //
-// If the ID is not provided, Snowball defaults to 0.
+// If the ID is not provided or is malformed, Snowball defaults to 0. Use LookupServerId to detect either case.
 func GetServerId() uint64 {
-	var serverId uint64
-	var err error
-	if serverId, err = GetenvInteger("SNOWBALL_NODE_ID"); err != nil {
-		fmt.Println("get epoch failed: envvar SNOWBALL_NODE_ID not found, using default")
+	serverId, err := lookupServerId()
+	if err != nil {
 		return 0
 	}
 
 	return serverId
 }
 
+// Reads the server ID from the SNOWBALL_NODE_ID environment variable. Unlike GetServerId, returns an error
+// naming the variable if it is not set (wrapping ErrEmptyEnvVar) or is malformed, rather than using 0.
+func LookupServerId() (uint64, error) {
+	return lookupEnvInteger("SNOWBALL_NODE_ID")
+}
+
+func lookupServerId() (uint64, error) {
+	serverId, err := LookupServerId()
+	if err != nil {
+		logFallback("SNOWBALL_NODE_ID", err, "server_id", 0)
+		return 0, err
+	}
+
+	return serverId, nil
+}
+
+// Reads an integer from the environment, with errors naming the variable.
+func lookupEnvInteger(key string) (uint64, error) {
+	str, err := GetenvStr(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not set: %w", key, err)
+	}
+
+	result, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is invalid: %q is not a non-negative integer", key, str)
+	}
+
+	return result, nil
+}
+
+// Logs that the environment variable could not be used and the given default is used instead.
+func logFallback(key string, err error, name string, value uint64) {
+	if errors.Is(err, ErrEmptyEnvVar) {
+		Logger().Warn(key+" is not set, using default", slog.Uint64(name, value))
+	} else {
+		Logger().Warn(key+" is invalid, using default", slog.Uint64(name, value), slog.Any("error", err))
+	}
+}
+
 // Alternatively, the server ID used by Snowball can be based on the machine or pod IP address, via the
 // SERVER_IP_ADDRESS system environment variable. This can be leveraged for containerized deployments that
 // are expected to scale, and providing multiple unique server IDs is more difficult.
-func GetServerIdFromIPAddress() uint64 {
+//
+// Both IPv4 and IPv6 addresses are supported. By default the server ID is the low ServerIdLen bits of the
+// address; SNOWBALL_IP_BITS can select fewer low bits, or SNOWBALL_IP_CIDR can name the network addresses are
+// allocated from, in which case the server ID is the address's offset within that network (see IPMapping).
+//
+// Returns ErrEmptyEnvVar if SERVER_IP_ADDRESS is not set, and an error if it or the mapping configuration
+// cannot be parsed.
+func GetServerIdFromIPAddress() (uint64, error) {
 	ipStr, err := GetenvStr("SERVER_IP_ADDRESS")
-	if err != nil || len(ipStr) == 0 {
-		fmt.Println("get server id failed: env var SERVER_IP_ADDRESS not found or undefined, using default")
-		return 0
+	if err != nil {
+		return 0, err
 	}
 
 	ip := net.ParseIP(ipStr)
-	return uint64(ip[3])
+	if ip == nil {
+		return 0, fmt.Errorf("get server id failed: SERVER_IP_ADDRESS %q is not a valid IP address", ipStr)
+	}
+
+	mapping, err := getIPMapping()
+	if err != nil {
+		return 0, err
+	}
+	return ServerIdFromIP(ip, mapping)
+}
+
+// Reads the IP address mapping from the SNOWBALL_IP_BITS and SNOWBALL_IP_CIDR environment variables.
+func getIPMapping() (IPMapping, error) {
+	var result IPMapping
+
+	bits, err := GetenvInteger("SNOWBALL_IP_BITS")
+	if err == nil {
+		if bits == 0 || bits > uint64(ServerIdLen) {
+			return result, fmt.Errorf("get server id failed: SNOWBALL_IP_BITS must be between 1 and %d", ServerIdLen)
+		}
+		result.Bits = uint8(bits)
+	} else if !errors.Is(err, ErrEmptyEnvVar) {
+		return result, fmt.Errorf("get server id failed: SNOWBALL_IP_BITS: %w", err)
+	}
+
+	cidr, err := GetenvStr("SNOWBALL_IP_CIDR")
+	if err == nil {
+		if _, result.Network, err = net.ParseCIDR(cidr); err != nil {
+			return result, fmt.Errorf("get server id failed: SNOWBALL_IP_CIDR: %w", err)
+		}
+	}
+
+	return result, nil
 }
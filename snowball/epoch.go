@@ -0,0 +1,121 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Returned (wrapped) for epochs that are well-formed but almost certainly a mistake, such as Unix seconds given
+// as milliseconds, or epochs whose timestamp range is already exhausted.
+var ErrImplausibleEpoch = errors.New("implausible epoch")
+
+const (
+	// Integer epochs in [secondsLikeMinMs, secondsLikeMaxMs) fall in the first years of Unix time when read as
+	// milliseconds, but between 2001 and 5138 when read as seconds, so they are almost certainly seconds.
+	secondsLikeMinMs = 1_000_000_000
+	secondsLikeMaxMs = 100_000_000_000
+	// Integer epochs from here on are past the year 5138 when read as milliseconds, so are more likely
+	// microseconds or nanoseconds.
+	microsLikeMinMs = 100_000_000_000_000
+
+	// InitNode warns when IDs will run out of timestamps within this long.
+	epochExhaustionWarning = 365 * 24 * time.Hour
+)
+
+// Parses an epoch given as milliseconds since the Unix epoch (e.g. "1704067200000"), an RFC 3339 timestamp
+// (e.g. "2024-01-01T00:00:00Z") or a date (e.g. "2024-01-01", taken as midnight UTC). The epoch is truncated to
+// millisecond precision.
+//
+// Integers that look like Unix seconds, microseconds or nanoseconds rather than milliseconds are rejected with
+// an error wrapping ErrImplausibleEpoch, as are epochs before the Unix epoch. Use CheckEpoch to also check the
+// epoch against the current time.
+func ParseEpoch(s string) (time.Time, error) {
+	if ms, err := strconv.ParseUint(s, 10, 64); err == nil {
+		switch {
+		case ms >= secondsLikeMinMs && ms < secondsLikeMaxMs:
+			return time.Time{}, fmt.Errorf("%w: %d looks like Unix seconds (%s), but the epoch is in milliseconds, "+
+				"i.e. %d", ErrImplausibleEpoch, ms, time.Unix(int64(ms), 0).UTC().Format(time.RFC3339), ms*1000)
+		case ms >= microsLikeMinMs:
+			return time.Time{}, fmt.Errorf("%w: %d looks like Unix microseconds or nanoseconds, but the epoch is "+
+				"in milliseconds", ErrImplausibleEpoch, ms)
+		}
+		return time.UnixMilli(int64(ms)), nil
+	}
+
+	var result time.Time
+	var err error
+	if len(s) == len(time.DateOnly) {
+		result, err = time.Parse(time.DateOnly, s)
+	} else {
+		result, err = time.Parse(time.RFC3339Nano, s)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"%q is not milliseconds since the Unix epoch, an RFC 3339 timestamp or a date (YYYY-MM-DD)", s,
+		)
+	}
+	if result.Before(time.Unix(0, 0)) {
+		return time.Time{}, fmt.Errorf("%w: %s is before the Unix epoch", ErrImplausibleEpoch, s)
+	}
+	return time.UnixMilli(result.UnixMilli()), nil
+}
+
+// Checks that IDs can be generated at the given time using the epoch: it must not be in the future, and the
+// timestamp section must not already be exhausted (see ValidUntil). Returns an error wrapping
+// ErrImplausibleEpoch otherwise.
+func CheckEpoch(epoch, now time.Time) error {
+	return DefaultLayout.CheckEpoch(epoch, now)
+}
+
+// Like CheckEpoch, but for IDs with the layout, e.g. SignedLayout, whose timestamps run out sooner.
+func (l Layout) CheckEpoch(epoch, now time.Time) error {
+	if epoch.After(now) {
+		return fmt.Errorf("%w: %s is in the future", ErrImplausibleEpoch, epoch.UTC().Format(time.RFC3339Nano))
+	}
+	if until := l.ValidUntil(epoch); until.Before(now) {
+		return fmt.Errorf("%w: %s is too far in the past, IDs ran out of timestamps on %s",
+			ErrImplausibleEpoch, epoch.UTC().Format(time.RFC3339Nano), until.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// Returns the last time at which IDs can be generated using the epoch, about 139 years after it, when the
+// timestamp section is exhausted.
+func ValidUntil(epoch time.Time) time.Time {
+	return DefaultLayout.ValidUntil(epoch)
+}
+
+// Returns the last time at which IDs with the layout can be generated using the epoch, when the timestamp
+// section is exhausted.
+func (l Layout) ValidUntil(epoch time.Time) time.Time {
+	return addMillis(epoch, l.MaxTimestamp())
+}
+
+// Returns the time from which IDs with the layout generated using the epoch have their top bit set, so exceed
+// math.MaxInt64 and read as negative numbers by clients that treat them as signed 64-bit integers, e.g. Java's
+// long or Postgres's BIGINT. For DefaultLayout this is about 69 years after the epoch. Returns false if the layout
+// leaves the top bit unused, as SignedLayout does, in which case IDs never cross into the sign bit.
+func (l Layout) SignBitCrossing(epoch time.Time) (time.Time, bool) {
+	shift := l.ServerIdLen + l.TypeLen + l.SequenceLen
+	if int(l.TimestampLen)+int(shift) < 64 {
+		return time.Time{}, false
+	}
+	return addMillis(epoch, 1<<(63-shift)), true
+}
+
+// Returns the time ms milliseconds after t. Unlike t.Add, works for times more than about 292 years apart.
+func addMillis(t time.Time, ms uint64) time.Time {
+	if ms <= math.MaxInt64/uint64(time.Millisecond) {
+		return t.Add(time.Duration(ms) * time.Millisecond)
+	}
+	return time.UnixMilli(t.UnixMilli() + int64(ms)).In(t.Location())
+}
+
+// Returns the last time at which the node can generate IDs. See ValidUntil.
+func (node *SnowballNode) ValidUntil() time.Time {
+	return node.layout.ValidUntil(node.Epoch())
+}
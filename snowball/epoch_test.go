@@ -0,0 +1,198 @@
+package snowball
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseEpoch(t *testing.T) {
+	tests := []struct {
+		name        string
+		arg         string
+		want        int64
+		wantErr     bool
+		implausible bool
+	}{
+		{name: "Milliseconds", arg: "1704121810000", want: 1704121810000},
+		{name: "Snowflake epoch", arg: "1288834974657", want: 1288834974657},
+		{name: "Unix epoch", arg: "0", want: 0},
+		{name: "RFC 3339", arg: "2024-01-01T15:10:10Z", want: 1704121810000},
+		{name: "RFC 3339 with offset", arg: "2024-01-01T16:10:10+01:00", want: 1704121810000},
+		{name: "RFC 3339 truncated", arg: "2024-01-01T15:10:10.0129Z", want: 1704121810012},
+		{name: "Date", arg: "2024-01-01", want: 1704067200000},
+		{name: "Seconds", arg: "1704121810", wantErr: true, implausible: true},
+		{name: "Microseconds", arg: "1704121810000000", wantErr: true, implausible: true},
+		{name: "Before Unix epoch", arg: "1969-12-31", wantErr: true, implausible: true},
+		{name: "Negative", arg: "-1", wantErr: true},
+		{name: "Malformed", arg: "yesterday", wantErr: true},
+		{name: "Malformed date", arg: "2024-13-01", wantErr: true},
+		{name: "Empty", arg: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEpoch(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEpoch(%q) error: %v, wantErr: %v", tt.arg, err, tt.wantErr)
+			}
+			if errors.Is(err, ErrImplausibleEpoch) != tt.implausible {
+				t.Errorf("ParseEpoch(%q) error: %v, want implausible: %v", tt.arg, err, tt.implausible)
+			}
+			if err == nil && got.UnixMilli() != tt.want {
+				t.Errorf("ParseEpoch(%q) = %d, want: %d", tt.arg, got.UnixMilli(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckEpoch(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		arg     time.Time
+		wantErr bool
+	}{
+		{name: "Past", arg: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Now", arg: now},
+		{name: "Future", arg: now.Add(time.Millisecond), wantErr: true},
+		{name: "Nearly exhausted", arg: now.Add(-time.Duration(MaxTimestamp) * time.Millisecond)},
+		{name: "Exhausted", arg: now.Add(-time.Duration(MaxTimestamp+1) * time.Millisecond), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckEpoch(tt.arg, now)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrImplausibleEpoch)) {
+				t.Errorf("CheckEpoch() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// SignedLayout's timestamps run out in about half the time.
+	signedMax := time.Duration(SignedLayout.MaxTimestamp()) * time.Millisecond
+	if err := SignedLayout.CheckEpoch(now.Add(-signedMax), now); err != nil {
+		t.Errorf("SignedLayout.CheckEpoch() of a nearly exhausted epoch error: %v, want: nil", err)
+	}
+	exhausted := now.Add(-signedMax - time.Millisecond)
+	if err := SignedLayout.CheckEpoch(exhausted, now); !errors.Is(err, ErrImplausibleEpoch) {
+		t.Errorf("SignedLayout.CheckEpoch() of an exhausted epoch error: %v, want: %v", err, ErrImplausibleEpoch)
+	}
+	if err := CheckEpoch(exhausted, now); err != nil {
+		t.Errorf("CheckEpoch() of an epoch only exhausted for SignedLayout error: %v, want: nil", err)
+	}
+}
+
+func TestValidUntil(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "2024-01-01T15:10:10Z")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, err := InitNode(false)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	want := time.Date(2163, 5, 15, 22, 45, 21, 103e6, time.UTC)
+	if got := node.ValidUntil(); !got.Equal(want) {
+		t.Errorf("ValidUntil() = %s, want: %s", got.UTC(), want)
+	}
+
+	id := node.GenerateID()
+	last, _ := DefaultLayout.Compose(MaxTimestamp, 32, 0)
+	if got := node.Decompose(last).Time; !got.Equal(want) || node.Decompose(id).Time.After(got) {
+		t.Errorf("Decompose(last ID).Time = %s, want: %s", got.UTC(), want)
+	}
+
+	if _, err := InitNode(false, WithEpoch(time.Now().Add(time.Hour))); !errors.Is(err, ErrImplausibleEpoch) {
+		t.Errorf("InitNode(WithEpoch(future)) error: %v, want: %v", err, ErrImplausibleEpoch)
+	}
+}
+
+func TestSignBitCrossing(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 15, 10, 10, 0, time.UTC)
+	want := time.Date(2093, 9, 7, 6, 57, 45, 552e6, time.UTC)
+	if got, ok := DefaultLayout.SignBitCrossing(epoch); !ok || !got.Equal(want) {
+		t.Errorf("DefaultLayout.SignBitCrossing() = %s, %v, want: %s, true", got.UTC(), ok, want)
+	}
+	if got, ok := TypedLayout(3).SignBitCrossing(epoch); !ok || !got.Equal(want) {
+		t.Errorf("TypedLayout(3).SignBitCrossing() = %s, %v, want: %s, true", got.UTC(), ok, want)
+	}
+	if _, ok := SignedLayout.SignBitCrossing(epoch); ok {
+		t.Errorf("SignedLayout.SignBitCrossing() reported a crossing, want none")
+	}
+
+	// The last ID before the crossing is the largest signed 64-bit integer.
+	last, _ := DefaultLayout.Compose(1<<41-1, uint64(MaxServerId), uint64(MaxSequence))
+	if last != math.MaxInt64 {
+		t.Errorf("Last ID before the sign bit crossing: %d, want: %d", last, int64(math.MaxInt64))
+	}
+}
+
+func TestWithSignedSafe(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "2024-01-01T15:10:10Z")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, err := InitNode(false, WithSignedSafe())
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if want := time.Date(2093, 9, 7, 6, 57, 45, 551e6, time.UTC); !node.ValidUntil().Equal(want) {
+		t.Errorf("ValidUntil() = %s, want: %s", node.ValidUntil().UTC(), want)
+	}
+	if id := node.GenerateID(); int64(id) <= 0 {
+		t.Errorf("GenerateID() = %d, want a positive signed 64-bit integer", int64(id))
+	}
+
+	// An epoch 80 years ago is fine for unsigned IDs, but signed ones have already run out.
+	old := time.Now().AddDate(-80, 0, 0)
+	if _, err := InitNode(false, WithEpoch(old)); err != nil {
+		t.Errorf("InitNode(WithEpoch(80 years ago)) error: %v", err)
+	}
+	if _, err := InitNode(false, WithEpoch(old), WithSignedSafe()); !errors.Is(err, ErrImplausibleEpoch) {
+		t.Errorf("InitNode(WithEpoch(80 years ago), WithSignedSafe()) error: %v, want: %v", err, ErrImplausibleEpoch)
+	}
+
+	// Once the timestamps run out, the node stops issuing IDs rather than setting the sign bit.
+	almost := time.Now().Add(-time.Duration(SignedLayout.MaxTimestamp())*time.Millisecond + 50*time.Millisecond)
+	node, err = InitNode(false, WithEpoch(almost), WithSignedSafe())
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := node.NextID(); !errors.Is(err, ErrTimestampExhausted) {
+		t.Errorf("NextID() error after the timestamps ran out: %v, want: %v", err, ErrTimestampExhausted)
+	}
+	if err := node.Ready(); !errors.Is(err, ErrTimestampExhausted) {
+		t.Errorf("Ready() after the timestamps ran out: %v, want: %v", err, ErrTimestampExhausted)
+	}
+}
+
+func TestInitNodeImplausibleEpoch(t *testing.T) {
+	tests := []struct {
+		name  string
+		epoch string
+	}{
+		{name: "Unix seconds", epoch: "1704121810"},
+		{name: "Future epoch", epoch: "4102444800000"},
+		{name: "Future date", epoch: "2100-01-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SNOWBALL_EPOCH_MS", tt.epoch)
+			t.Setenv("SNOWBALL_NODE_ID", "32")
+
+			// Falling back to the default epoch would silently change the timestamps of new IDs.
+			if _, err := InitNode(false); !errors.Is(err, ErrImplausibleEpoch) {
+				t.Errorf("InitNode() error: %v, want: %v", err, ErrImplausibleEpoch)
+			}
+			// GetEpoch, which cannot fail, falls back to the default, as it does for any epoch LookupEpoch rejects.
+			if _, err := LookupEpoch(); !errors.Is(err, ErrImplausibleEpoch) {
+				t.Errorf("LookupEpoch() error: %v, want: %v", err, ErrImplausibleEpoch)
+			}
+			if got := GetEpoch(); got != DefaultEpochMs {
+				t.Errorf("GetEpoch() = %d, want the default epoch %d", got, DefaultEpochMs)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Describes how an IP address is mapped onto the server ID range.
+//
+// By default, the server ID is taken from the low ServerIdLen bits of the address, so that e.g. pods in a
+// /21 (IPv4) or /117 (IPv6) subnet all receive distinct server IDs. Deployments with a larger subnet can
+// instead configure the network their addresses are allocated from; the server ID is then the address's
+// offset within that network, and addresses beyond MaxServerId are rejected rather than silently wrapped.
+type IPMapping struct {
+	// Number of low bits of the address to use as the server ID. Defaults to ServerIdLen if 0. Ignored if
+	// Network is set.
+	Bits uint8
+	// If set, the server ID is the offset of the address within this network.
+	Network *net.IPNet
+}
+
+// Derives a server ID from an IPv4 or IPv6 address using the given mapping.
+func ServerIdFromIP(ip net.IP, mapping IPMapping) (uint64, error) {
+	addr := ip.To4()
+	if addr == nil {
+		addr = ip.To16()
+	}
+	if addr == nil {
+		return 0, errors.New("get server id failed: invalid IP address")
+	}
+
+	if mapping.Network != nil {
+		return offsetInNetwork(addr, mapping.Network)
+	}
+
+	bits := mapping.Bits
+	if bits == 0 {
+		bits = ServerIdLen
+	}
+	if bits > ServerIdLen {
+		return 0, fmt.Errorf("get server id failed: cannot use %d address bits, server IDs are %d bits", bits, ServerIdLen)
+	}
+
+	// Only the low 8 bytes can contribute to the server ID; higher bytes are shifted out.
+	var low uint64
+	for _, b := range addr {
+		low = low<<8 | uint64(b)
+	}
+	return low & (1<<bits - 1), nil
+}
+
+// Returns the offset of the address within the network, failing if it is not part of the network or the
+// offset does not fit in the server ID range.
+func offsetInNetwork(addr net.IP, network *net.IPNet) (uint64, error) {
+	if !network.Contains(addr) {
+		return 0, fmt.Errorf("get server id failed: address %s is not in network %s", addr, network)
+	}
+
+	mask := network.Mask
+	if len(mask) != len(addr) {
+		// Contains accepts IPv4 addresses in IPv4-mapped IPv6 networks and vice versa; align the mask to
+		// the address's length.
+		if len(mask) > len(addr) {
+			mask = mask[len(mask)-len(addr):]
+		} else {
+			addr = addr[len(addr)-len(mask):]
+		}
+	}
+
+	var offset uint64
+	for i, b := range addr {
+		host := uint64(b &^ mask[i])
+		if offset > uint64(MaxServerId) {
+			break
+		}
+		offset = offset<<8 | host
+	}
+	if offset > uint64(MaxServerId) {
+		return 0, fmt.Errorf(
+			"get server id failed: address %s is beyond the first %d addresses of network %s",
+			addr, uint64(MaxServerId)+1, network,
+		)
+	}
+
+	return offset, nil
+}
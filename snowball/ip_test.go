@@ -0,0 +1,148 @@
+package snowball
+
+import (
+	"net"
+	"testing"
+)
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+func TestServerIdFromIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		ip      string
+		mapping IPMapping
+		want    uint64
+		wantErr bool
+	}{
+		{name: "IPv4 low bits", ip: "10.0.5.7", want: 0x507},
+		{name: "IPv4 uses 11 bits", ip: "10.1.2.3", want: 0x203},
+		{name: "IPv4 low 8 bits", ip: "10.0.5.7", mapping: IPMapping{Bits: 8}, want: 7},
+		{name: "IPv4-mapped IPv6", ip: "::ffff:10.0.5.7", want: 0x507},
+		{name: "IPv6 low bits", ip: "fd00::1:2a", want: 0x2a},
+		{name: "IPv6 low 4 bits", ip: "fd00::1:2b", mapping: IPMapping{Bits: 4}, want: 0xb},
+		{name: "Too many bits", ip: "10.0.5.7", mapping: IPMapping{Bits: 12}, wantErr: true},
+		{
+			name:    "IPv4 network offset",
+			ip:      "10.0.7.255",
+			mapping: IPMapping{Network: mustParseCIDR("10.0.0.0/16")},
+			want:    2047,
+		},
+		{
+			name:    "IPv4 network offset too large",
+			ip:      "10.0.8.0",
+			mapping: IPMapping{Network: mustParseCIDR("10.0.0.0/16")},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4 network offset too large in higher octet",
+			ip:      "10.1.0.1",
+			mapping: IPMapping{Network: mustParseCIDR("10.0.0.0/8")},
+			wantErr: true,
+		},
+		{
+			name:    "IPv4 network offset ignores Bits",
+			ip:      "172.16.4.1",
+			mapping: IPMapping{Bits: 8, Network: mustParseCIDR("172.16.4.0/22")},
+			want:    1,
+		},
+		{
+			name:    "Address outside network",
+			ip:      "192.168.0.1",
+			mapping: IPMapping{Network: mustParseCIDR("10.0.0.0/16")},
+			wantErr: true,
+		},
+		{name: "IPv6 network offset", ip: "fd00::7ff", mapping: IPMapping{Network: mustParseCIDR("fd00::/64")}, want: 2047},
+		{
+			name:    "IPv4-mapped network",
+			ip:      "10.0.0.9",
+			mapping: IPMapping{Network: mustParseCIDR("::ffff:10.0.0.0/120")},
+			want:    9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := ServerIdFromIP(net.ParseIP(tt.ip), tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ServerIdFromIP() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if id != tt.want {
+				t.Errorf("ServerIdFromIP() server ID: %v, want: %v", id, tt.want)
+			}
+		})
+	}
+
+	if _, err := ServerIdFromIP(nil, IPMapping{}); err == nil {
+		t.Errorf("ServerIdFromIP(nil) did not fail")
+	}
+}
+
+func TestGetServerIdFromIPAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "Default mapping", env: map[string]string{"SERVER_IP_ADDRESS": "10.1.2.3"}, want: 0x203},
+		{name: "Bits", env: map[string]string{"SERVER_IP_ADDRESS": "10.1.2.3", "SNOWBALL_IP_BITS": "8"}, want: 3},
+		{
+			name: "CIDR",
+			env:  map[string]string{"SERVER_IP_ADDRESS": "10.1.2.3", "SNOWBALL_IP_CIDR": "10.1.0.0/16"},
+			want: 0x203,
+		},
+		{name: "Unparseable address", env: map[string]string{"SERVER_IP_ADDRESS": "10.1.2"}, wantErr: true},
+		{name: "Missing address", env: map[string]string{}, wantErr: true},
+		{
+			name:    "Malformed bits",
+			env:     map[string]string{"SERVER_IP_ADDRESS": "10.1.2.3", "SNOWBALL_IP_BITS": "eight"},
+			wantErr: true,
+		},
+		{
+			name:    "Malformed CIDR",
+			env:     map[string]string{"SERVER_IP_ADDRESS": "10.1.2.3", "SNOWBALL_IP_CIDR": "10.1.0.0"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SERVER_IP_ADDRESS", "SNOWBALL_IP_BITS", "SNOWBALL_IP_CIDR"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			id, err := GetServerIdFromIPAddress()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetServerIdFromIPAddress() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if id != tt.want {
+				t.Errorf("GetServerIdFromIPAddress() server ID: %v, want: %v", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitNodeWithIPAddress(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+
+	t.Setenv("SERVER_IP_ADDRESS", "10.1.2.3")
+	node, err := InitNode(true)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if node.ServerId() != 0x203 {
+		t.Errorf("InitNode() server ID: %v, want: %v", node.ServerId(), 0x203)
+	}
+
+	t.Setenv("SERVER_IP_ADDRESS", "not-an-ip")
+	if _, err := InitNode(true); err == nil {
+		t.Errorf("No error occurred running InitNode with an unparseable SERVER_IP_ADDRESS")
+	}
+}
@@ -0,0 +1,129 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrSectionOverflow = errors.New("compose failed: section overflow")
+
+// Describes the bit widths of each section of a Snowball ID. Sections are packed from the most significant
+// bit downward in the order timestamp, server ID, type, sequence.
+type Layout struct {
+	TimestampLen uint8
+	ServerIdLen  uint8
+	// Length of the optional type section, which identifies the TypeGenerator that issued the ID. 0 if IDs
+	// have no type section, as in DefaultLayout.
+	TypeLen     uint8
+	SequenceLen uint8
+}
+
+// The layout used by Snowball IDs generated by a SnowballNode.
+var DefaultLayout = Layout{
+	TimestampLen: TimestampLen,
+	ServerIdLen:  ServerIdLen,
+	SequenceLen:  SequenceLen,
+}
+
+// A layout like DefaultLayout, but with the top bit of the timestamp section left unused, so that IDs always fit
+// in a signed 64-bit integer. Timestamps run out after about 69 years rather than 139. Used by nodes created with
+// WithSignedSafe.
+var SignedLayout = Layout{
+	TimestampLen: TimestampLen - 1,
+	ServerIdLen:  ServerIdLen,
+	SequenceLen:  SequenceLen,
+}
+
+// Checks that the sections of the layout, other than the type section, are non-empty and that they fit within
+// 64 bits.
+func (l Layout) Validate() error {
+	if l.TimestampLen == 0 || l.ServerIdLen == 0 || l.SequenceLen == 0 {
+		return errors.New("invalid layout: section lengths must be greater than 0")
+	}
+	if int(l.TimestampLen)+int(l.ServerIdLen)+int(l.TypeLen)+int(l.SequenceLen) > 64 {
+		return errors.New("invalid layout: section lengths must not exceed 64 bits in total")
+	}
+
+	return nil
+}
+
+// Maximum value for the timestamp section.
+func (l Layout) MaxTimestamp() uint64 {
+	return 1<<l.TimestampLen - 1
+}
+
+// Maximum value for the Server ID section.
+func (l Layout) MaxServerId() uint64 {
+	return 1<<l.ServerIdLen - 1
+}
+
+// Maximum value for the type section.
+func (l Layout) MaxType() uint64 {
+	return 1<<l.TypeLen - 1
+}
+
+// Maximum value for the sequence section.
+func (l Layout) MaxSequence() uint64 {
+	return 1<<l.SequenceLen - 1
+}
+
+// Packs a timestamp, server ID and sequence into a Snowball ID, with a type of 0. Fails if any section does
+// not fit within the number of bits the layout assigns to it.
+func (l Layout) Compose(timestamp, serverId, sequence uint64) (SnowballID, error) {
+	return l.ComposeTyped(timestamp, serverId, 0, sequence)
+}
+
+// Packs a timestamp, server ID, type and sequence into a Snowball ID. Fails if any section does not fit within
+// the number of bits the layout assigns to it.
+func (l Layout) ComposeTyped(timestamp, serverId, typ, sequence uint64) (SnowballID, error) {
+	if err := l.Validate(); err != nil {
+		return 0, err
+	}
+	if timestamp > l.MaxTimestamp() {
+		return 0, fmt.Errorf("%w: timestamp %d does not fit in %d bits", ErrSectionOverflow, timestamp, l.TimestampLen)
+	}
+	if serverId > l.MaxServerId() {
+		return 0, fmt.Errorf("%w: server ID %d does not fit in %d bits", ErrSectionOverflow, serverId, l.ServerIdLen)
+	}
+	if typ > l.MaxType() {
+		return 0, fmt.Errorf("%w: type %d does not fit in %d bits", ErrSectionOverflow, typ, l.TypeLen)
+	}
+	if sequence > l.MaxSequence() {
+		return 0, fmt.Errorf("%w: sequence %d does not fit in %d bits", ErrSectionOverflow, sequence, l.SequenceLen)
+	}
+
+	return SnowballID(
+		timestamp<<(l.ServerIdLen+l.TypeLen+l.SequenceLen) | serverId<<(l.TypeLen+l.SequenceLen) |
+			typ<<l.SequenceLen | sequence,
+	), nil
+}
+
+// Unpacks a Snowball ID into its timestamp, server ID and sequence sections. The type section, if any, is
+// dropped; use DecomposeTyped to recover it.
+func (l Layout) Decompose(id SnowballID) (timestamp, serverId, sequence uint64) {
+	timestamp, serverId, _, sequence = l.DecomposeTyped(id)
+	return timestamp, serverId, sequence
+}
+
+// Unpacks a Snowball ID into its timestamp, server ID, type and sequence sections.
+func (l Layout) DecomposeTyped(id SnowballID) (timestamp, serverId, typ, sequence uint64) {
+	v := uint64(id)
+	timestamp = (v >> (l.ServerIdLen + l.TypeLen + l.SequenceLen)) & l.MaxTimestamp()
+	serverId = (v >> (l.TypeLen + l.SequenceLen)) & l.MaxServerId()
+	typ = (v >> l.SequenceLen) & l.MaxType()
+	sequence = v & l.MaxSequence()
+	return timestamp, serverId, typ, sequence
+}
+
+// Returns the layout of IDs issued by TypeGenerators with the given type length: DefaultLayout, with the top
+// typeLen bits of the sequence section used as the type section instead. typeLen must be less than SequenceLen.
+func TypedLayout(typeLen uint8) Layout {
+	return DefaultLayout.typed(typeLen)
+}
+
+// Returns the layout with the top typeLen bits of the sequence section used as the type section instead.
+func (l Layout) typed(typeLen uint8) Layout {
+	l.TypeLen = typeLen
+	l.SequenceLen -= typeLen
+	return l
+}
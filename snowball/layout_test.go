@@ -0,0 +1,113 @@
+package snowball
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLayoutCompose(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  Layout
+		args    [3]uint64
+		want    SnowballID
+		wantErr bool
+	}{
+		{
+			name:   "Default layout",
+			layout: DefaultLayout,
+			args:   [3]uint64{4682111543, 32, 0},
+			want:   19638199173316608,
+		},
+		{
+			name:   "Snowflake-like layout",
+			layout: Layout{TimestampLen: 41, ServerIdLen: 10, SequenceLen: 12},
+			args:   [3]uint64{1, 1, 1},
+			want:   1<<22 | 1<<12 | 1,
+		},
+		{
+			name:    "Sequence too wide",
+			layout:  DefaultLayout,
+			args:    [3]uint64{1, 1, 2048},
+			wantErr: true,
+		},
+		{
+			name:    "Server ID too wide",
+			layout:  DefaultLayout,
+			args:    [3]uint64{1, 2048, 1},
+			wantErr: true,
+		},
+		{
+			name:    "Timestamp too wide",
+			layout:  DefaultLayout,
+			args:    [3]uint64{1 << 42, 1, 1},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid layout",
+			layout:  Layout{TimestampLen: 42, ServerIdLen: 12, SequenceLen: 11},
+			args:    [3]uint64{1, 1, 1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tt.layout.Compose(tt.args[0], tt.args[1], tt.args[2])
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Compose() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if id != tt.want {
+				t.Errorf("Compose() encoded: %v, want: %v", id, tt.want)
+			}
+			if err != nil && tt.layout.Validate() == nil && !errors.Is(err, ErrSectionOverflow) {
+				t.Errorf("Compose() error: %v, want ErrSectionOverflow", err)
+			}
+		})
+	}
+}
+
+func TestLayoutDecompose(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	for i := 0; i < 10000; i++ {
+		id := node.GenerateID()
+		timestamp, serverId, sequence := DefaultLayout.Decompose(id)
+		if serverId != 32 {
+			t.Fatalf("Decompose() server ID: %v, want: 32", serverId)
+		}
+
+		composed, err := DefaultLayout.Compose(timestamp, serverId, sequence)
+		if err != nil || composed != id {
+			t.Fatalf("Compose() encoded: %v, want: %v (error: %v)", composed, id, err)
+		}
+	}
+}
+
+func TestLayoutComposeTyped(t *testing.T) {
+	layout := TypedLayout(3)
+	if layout.SequenceLen != 8 || layout.Validate() != nil {
+		t.Fatalf("TypedLayout(3): %+v, want a valid layout with an 8-bit sequence", layout)
+	}
+
+	id, err := layout.ComposeTyped(4682111543, 32, 5, 255)
+	if err != nil {
+		t.Fatalf("Error occurred running ComposeTyped: %s", err)
+	}
+	if want := SnowballID(4682111543<<22 | 32<<11 | 5<<8 | 255); id != want {
+		t.Errorf("ComposeTyped() encoded: %v, want: %v", id, want)
+	}
+	if timestamp, serverId, typ, sequence := layout.DecomposeTyped(id); timestamp != 4682111543 ||
+		serverId != 32 || typ != 5 || sequence != 255 {
+		t.Errorf("DecomposeTyped() = %d, %d, %d, %d, want: 4682111543, 32, 5, 255", timestamp, serverId, typ, sequence)
+	}
+
+	if _, err := layout.ComposeTyped(1, 1, 8, 1); !errors.Is(err, ErrSectionOverflow) {
+		t.Errorf("ComposeTyped() with a type too wide: %v, want ErrSectionOverflow", err)
+	}
+	if _, err := layout.ComposeTyped(1, 1, 1, 256); !errors.Is(err, ErrSectionOverflow) {
+		t.Errorf("ComposeTyped() with a sequence too wide: %v, want ErrSectionOverflow", err)
+	}
+}
@@ -0,0 +1,56 @@
+package snowball
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// The logger for diagnostics not tied to a node, and for nodes created without WithLogger.
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	SetLogger(nil)
+}
+
+// Sets the logger Snowball writes diagnostics to, such as falling back to a default epoch or server ID. By
+// default nothing is logged; passing nil restores the default.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(discardHandler{})
+	}
+	logger.Store(l)
+}
+
+// Returns the logger set with SetLogger.
+func Logger() *slog.Logger {
+	return logger.Load()
+}
+
+// Writes the node's diagnostics, such as clock rollbacks and suspensions, to the given logger rather than the
+// one set with SetLogger.
+func WithLogger(l *slog.Logger) Option {
+	return func(node *SnowballNode) {
+		node.logger = l
+	}
+}
+
+// Returns the logger for the node's diagnostics.
+func (node *SnowballNode) log() *slog.Logger {
+	result := node.logger
+	if result == nil {
+		result = Logger()
+	}
+	if node.namespace != "" {
+		result = result.With(slog.String("namespace", node.namespace))
+	}
+	return result
+}
+
+// A handler that discards all records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
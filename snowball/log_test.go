@@ -0,0 +1,52 @@
+package snowball
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	t.Setenv("SNOWBALL_NODE_ID", "")
+	GetServerId()
+	if !strings.Contains(buf.String(), "SNOWBALL_NODE_ID is not set") {
+		t.Errorf("GetServerId() logged %q, want a warning naming SNOWBALL_NODE_ID", buf.String())
+	}
+
+	buf.Reset()
+	t.Setenv("SNOWBALL_EPOCH_MS", "yesterday")
+	if GetEpoch() != DefaultEpochMs || !strings.Contains(buf.String(), "SNOWBALL_EPOCH_MS is invalid") {
+		t.Errorf("GetEpoch() logged %q, want a warning naming SNOWBALL_EPOCH_MS", buf.String())
+	}
+
+	buf.Reset()
+	SetLogger(nil)
+	GetServerId()
+	if buf.Len() != 0 {
+		t.Errorf("GetServerId() logged %q after SetLogger(nil), want nothing", buf.String())
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	var buf bytes.Buffer
+	node, _ := InitNode(false, WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	if !strings.Contains(buf.String(), "server_id=32") {
+		t.Errorf("InitNode() logged %q, want the node's server ID", buf.String())
+	}
+
+	buf.Reset()
+	resume := node.Suspend(errors.New("lease lost"))
+	resume()
+	if !strings.Contains(buf.String(), "lease lost") || !strings.Contains(buf.String(), "resumed") {
+		t.Errorf("Suspend() logged %q, want the suspension and resumption", buf.String())
+	}
+}
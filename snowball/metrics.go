@@ -0,0 +1,93 @@
+package snowball
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus collector for metrics about ID generation. Metrics are only recorded for nodes created with the
+// WithMetrics option, and are labeled by the node's server ID and namespace (empty unless set with
+// WithNamespace). A single Metrics can be shared by several nodes.
+//
+// Snowball never registers metrics itself; register the collector with the registry of your choice:
+//
+//	metrics := snowball.NewMetrics()
+//	prometheus.MustRegister(metrics)
+//	node, err := snowball.InitNode(false, snowball.WithMetrics(metrics))
+type Metrics struct {
+	idsIssued         *prometheus.CounterVec
+	sequenceExhausted *prometheus.CounterVec
+	clockRollbacks    *prometheus.CounterVec
+	generationLatency *prometheus.HistogramVec
+	bufferUnderflows  *prometheus.CounterVec
+}
+
+// Creates and returns a new, unregistered metrics collector.
+func NewMetrics() *Metrics {
+	labels := []string{"server_id", "namespace"}
+	return &Metrics{
+		idsIssued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "snowball",
+			Name:      "ids_issued_total",
+			Help:      "Number of Snowball IDs issued.",
+		}, labels),
+		sequenceExhausted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "snowball",
+			Name:      "sequence_exhaustion_waits_total",
+			Help:      "Number of times generation waited for the next millisecond because the sequence was exhausted.",
+		}, labels),
+		clockRollbacks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "snowball",
+			Name:      "clock_rollback_events_total",
+			Help:      "Number of times generation waited because the clock was behind the last issued timestamp.",
+		}, labels),
+		generationLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "snowball",
+			Name:      "generation_duration_seconds",
+			Help:      "Time taken to generate an ID or batch of IDs, including waiting for the node's lock.",
+			Buckets:   []float64{.000001, .0000025, .000005, .00001, .000025, .00005, .0001, .00025, .0005, .001, .0025},
+		}, labels),
+		bufferUnderflows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "snowball",
+			Name:      "buffer_underflows_total",
+			Help:      "Number of IDs requested from an empty Buffer, which were generated directly instead.",
+		}, labels),
+	}
+}
+
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.idsIssued.Describe(ch)
+	m.sequenceExhausted.Describe(ch)
+	m.clockRollbacks.Describe(ch)
+	m.generationLatency.Describe(ch)
+	m.bufferUnderflows.Describe(ch)
+}
+
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.idsIssued.Collect(ch)
+	m.sequenceExhausted.Collect(ch)
+	m.clockRollbacks.Collect(ch)
+	m.generationLatency.Collect(ch)
+	m.bufferUnderflows.Collect(ch)
+}
+
+// The metrics of a single node, with labels already applied.
+type nodeMetrics struct {
+	idsIssued         prometheus.Counter
+	sequenceExhausted prometheus.Counter
+	clockRollbacks    prometheus.Counter
+	generationLatency prometheus.Observer
+	bufferUnderflows  prometheus.Counter
+}
+
+func (m *Metrics) forNode(serverId uint64, namespace string) *nodeMetrics {
+	labels := []string{strconv.FormatUint(serverId, 10), namespace}
+	return &nodeMetrics{
+		idsIssued:         m.idsIssued.WithLabelValues(labels...),
+		sequenceExhausted: m.sequenceExhausted.WithLabelValues(labels...),
+		clockRollbacks:    m.clockRollbacks.WithLabelValues(labels...),
+		generationLatency: m.generationLatency.WithLabelValues(labels...),
+		bufferUnderflows:  m.bufferUnderflows.WithLabelValues(labels...),
+	}
+}
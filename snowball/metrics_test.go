@@ -0,0 +1,103 @@
+package snowball
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Gathers the metrics from the collector, keyed by metric name.
+func gatherMetrics(t *testing.T, m *Metrics) map[string]*dto.Metric {
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(m)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error: %v", err)
+	}
+
+	result := map[string]*dto.Metric{}
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			for _, label := range metric.Label {
+				if label.GetName() == "server_id" && label.GetValue() != "32" {
+					t.Errorf("Metric %s has server_id label %s, want: 32", family.GetName(), label.GetValue())
+				}
+			}
+			result[family.GetName()] = metric
+		}
+	}
+	return result
+}
+
+func TestMetrics(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	m := NewMetrics()
+	node, err := InitNode(false, WithMetrics(m))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		node.GenerateID()
+	}
+	node.GenerateIDs(5000)
+
+	metrics := gatherMetrics(t, m)
+	if got := metrics["snowball_ids_issued_total"].GetCounter().GetValue(); got != 15000 {
+		t.Errorf("snowball_ids_issued_total: %v, want: 15000", got)
+	}
+	if got := metrics["snowball_generation_duration_seconds"].GetHistogram().GetSampleCount(); got != 10001 {
+		t.Errorf("snowball_generation_duration_seconds count: %v, want: 10001", got)
+	}
+	// 5000 IDs cannot fit in a single millisecond's worth of sequence numbers.
+	if got := metrics["snowball_sequence_exhaustion_waits_total"].GetCounter().GetValue(); got < 1 {
+		t.Errorf("snowball_sequence_exhaustion_waits_total: %v, want at least 1", got)
+	}
+	if _, ok := metrics["snowball_clock_rollback_events_total"]; !ok {
+		t.Errorf("snowball_clock_rollback_events_total was not collected")
+	}
+}
+
+func BenchmarkGenerateIDWithMetrics(b *testing.B) {
+	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	b.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false, WithMetrics(NewMetrics()))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_ = node.GenerateID()
+	}
+}
+
+func TestMetricsNamespace(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	m := NewMetrics()
+	orders, err := InitNode(false, WithMetrics(m), WithNamespace("orders"))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	users, err := InitNode(false, WithMetrics(m), WithNamespace("users"))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if orders.Namespace() != "orders" {
+		t.Errorf("Namespace() = %q, want: orders", orders.Namespace())
+	}
+
+	orders.GenerateIDs(3)
+	users.GenerateID()
+	for namespace, want := range map[string]float64{"orders": 3, "users": 1} {
+		if got := testutil.ToFloat64(m.idsIssued.WithLabelValues("32", namespace)); got != want {
+			t.Errorf("snowball_ids_issued_total{namespace=%q}: %v, want: %v", namespace, got, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// Default pattern for extracting an ordinal from a hostname, matching the "-<n>" suffix Kubernetes gives to
+// StatefulSet pods (e.g. "snowball-3").
+var DefaultOrdinalPattern = regexp.MustCompile(`-(\d+)$`)
+
+// Provides the server ID from an ordinal embedded in the hostname, such as the index of a Kubernetes
+// StatefulSet pod.
+//
+// The server ID is Offset plus the ordinal, so that several StatefulSets (or clusters) can share the server ID
+// space without overlapping by assigning each a distinct block: e.g. Offset 0 and Range 1024 for one, and
+// Offset 1024 and Range 1024 for another.
+type OrdinalServerIDProvider struct {
+	// Hostname to extract the ordinal from. If empty, the HOSTNAME environment variable is used, falling back
+	// to the hostname reported by the kernel.
+	Hostname string
+	// Pattern matching the ordinal. If the pattern has a capturing group, the first group is the ordinal;
+	// otherwise the whole match is. Defaults to DefaultOrdinalPattern.
+	Pattern *regexp.Regexp
+	// Server ID assigned to ordinal 0.
+	Offset uint64
+	// Number of server IDs reserved for this set, starting at Offset. Ordinals at or beyond Range are rejected.
+	// If 0, the set extends to MaxServerId.
+	Range uint64
+}
+
+// Creates a provider configured from the environment: SNOWBALL_ORDINAL_PATTERN overrides the pattern, and
+// SNOWBALL_ORDINAL_OFFSET and SNOWBALL_ORDINAL_RANGE set the block of server IDs the ordinals map onto.
+func NewOrdinalServerIDProviderFromEnv() (*OrdinalServerIDProvider, error) {
+	result := &OrdinalServerIDProvider{}
+
+	if pattern, err := GetenvStr("SNOWBALL_ORDINAL_PATTERN"); err == nil {
+		if result.Pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("get server id failed: SNOWBALL_ORDINAL_PATTERN: %w", err)
+		}
+	}
+
+	var err error
+	if result.Offset, err = GetenvInteger("SNOWBALL_ORDINAL_OFFSET"); err != nil && !errors.Is(err, ErrEmptyEnvVar) {
+		return nil, fmt.Errorf("get server id failed: SNOWBALL_ORDINAL_OFFSET: %w", err)
+	}
+	if result.Range, err = GetenvInteger("SNOWBALL_ORDINAL_RANGE"); err != nil && !errors.Is(err, ErrEmptyEnvVar) {
+		return nil, fmt.Errorf("get server id failed: SNOWBALL_ORDINAL_RANGE: %w", err)
+	}
+
+	// Compare the range against the room left after the offset, as their sum could overflow.
+	if result.Offset > uint64(MaxServerId) || result.Range > uint64(MaxServerId)+1-result.Offset {
+		return nil, fmt.Errorf(
+			"get server id failed: ordinal block starting at %d with range %d exceeds the maximum server ID %d",
+			result.Offset, result.Range, MaxServerId,
+		)
+	}
+	return result, nil
+}
+
+func (p *OrdinalServerIDProvider) ServerId() (uint64, error) {
+	hostname := p.Hostname
+	if hostname == "" {
+		hostname, _ = GetenvStr("HOSTNAME")
+	}
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	if hostname == "" {
+		return 0, unavailableError("hostname is not known")
+	}
+
+	ordinal, err := p.ordinal(hostname)
+	if err != nil {
+		return 0, err
+	}
+
+	if p.Range != 0 && ordinal >= p.Range {
+		return 0, fmt.Errorf(
+			"get server id failed: ordinal %d of hostname %q is beyond the range of %d server IDs",
+			ordinal, hostname, p.Range,
+		)
+	}
+	if ordinal > uint64(MaxServerId) || p.Offset > uint64(MaxServerId)-ordinal {
+		return 0, fmt.Errorf(
+			"get server id failed: ordinal %d of hostname %q with offset %d exceeds the maximum server ID %d",
+			ordinal, hostname, p.Offset, MaxServerId,
+		)
+	}
+
+	return p.Offset + ordinal, nil
+}
+
+func (p *OrdinalServerIDProvider) String() string {
+	return "ordinal"
+}
+
+// Extracts the ordinal from the hostname. Returns an error matching ErrServerIdUnavailable if the hostname
+// does not match the pattern, e.g. because the service is not running in a StatefulSet.
+func (p *OrdinalServerIDProvider) ordinal(hostname string) (uint64, error) {
+	pattern := p.Pattern
+	if pattern == nil {
+		pattern = DefaultOrdinalPattern
+	}
+
+	match := pattern.FindStringSubmatch(hostname)
+	if match == nil {
+		return 0, unavailableError(fmt.Sprintf("hostname %q does not match %s", hostname, pattern))
+	}
+
+	str := match[0]
+	if len(match) > 1 {
+		str = match[1]
+	}
+	result, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("get server id failed: ordinal %q of hostname %q: %w", str, hostname, err)
+	}
+
+	return result, nil
+}
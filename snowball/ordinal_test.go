@@ -0,0 +1,151 @@
+package snowball
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"testing"
+)
+
+func TestOrdinalServerIDProvider(t *testing.T) {
+	tests := []struct {
+		name            string
+		arg             OrdinalServerIDProvider
+		want            uint64
+		wantErr         bool
+		wantUnavailable bool
+	}{
+		{name: "StatefulSet pod", arg: OrdinalServerIDProvider{Hostname: "snowball-3"}, want: 3},
+		{name: "Offset", arg: OrdinalServerIDProvider{Hostname: "snowball-3", Offset: 1024, Range: 1024}, want: 1027},
+		{
+			name: "Last in range",
+			arg:  OrdinalServerIDProvider{Hostname: "snowball-1023", Offset: 1024, Range: 1024},
+			want: 2047,
+		},
+		{name: "Beyond range", arg: OrdinalServerIDProvider{Hostname: "snowball-8", Range: 8}, wantErr: true},
+		{name: "Beyond max server ID", arg: OrdinalServerIDProvider{Hostname: "snowball-2048"}, wantErr: true},
+		{
+			name:    "Offset beyond max server ID",
+			arg:     OrdinalServerIDProvider{Hostname: "snowball-1", Offset: 2047},
+			wantErr: true,
+		},
+		{
+			name:    "Offset wrapping around",
+			arg:     OrdinalServerIDProvider{Hostname: "snowball-1", Offset: math.MaxUint64},
+			wantErr: true,
+		},
+		{name: "No ordinal", arg: OrdinalServerIDProvider{Hostname: "laptop"}, wantErr: true, wantUnavailable: true},
+		{
+			name: "Pattern with group",
+			arg:  OrdinalServerIDProvider{Hostname: "ids-7.us-east.example.com", Pattern: regexp.MustCompile(`^ids-(\d+)\.`)},
+			want: 7,
+		},
+		{
+			name: "Pattern without group",
+			arg:  OrdinalServerIDProvider{Hostname: "node42", Pattern: regexp.MustCompile(`\d+$`)},
+			want: 42,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tt.arg.ServerId()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ServerId() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrServerIdUnavailable) != tt.wantUnavailable {
+				t.Errorf("ServerId() error: %v, want unavailable: %v", err, tt.wantUnavailable)
+			}
+			if id != tt.want {
+				t.Errorf("ServerId() = %v, want: %v", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOrdinalServerIDProviderFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    uint64
+		wantErr bool
+	}{
+		{name: "Hostname", env: map[string]string{"HOSTNAME": "snowball-5"}, want: 5},
+		{
+			name: "Offset",
+			env:  map[string]string{"HOSTNAME": "snowball-5", "SNOWBALL_ORDINAL_OFFSET": "512", "SNOWBALL_ORDINAL_RANGE": "256"},
+			want: 517,
+		},
+		{
+			name: "Pattern",
+			env:  map[string]string{"HOSTNAME": "worker_12_b", "SNOWBALL_ORDINAL_PATTERN": `_(\d+)_`},
+			want: 12,
+		},
+		{
+			name:    "Invalid pattern",
+			env:     map[string]string{"HOSTNAME": "snowball-5", "SNOWBALL_ORDINAL_PATTERN": `(\d+`},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid offset",
+			env:     map[string]string{"HOSTNAME": "snowball-5", "SNOWBALL_ORDINAL_OFFSET": "-1"},
+			wantErr: true,
+		},
+		{
+			name: "Block beyond max server ID",
+			env: map[string]string{
+				"HOSTNAME": "snowball-5", "SNOWBALL_ORDINAL_OFFSET": "1024", "SNOWBALL_ORDINAL_RANGE": "2048",
+			},
+			wantErr: true,
+		},
+		{
+			name: "Range wrapping around",
+			env: map[string]string{
+				"HOSTNAME": "snowball-5", "SNOWBALL_ORDINAL_OFFSET": "1", "SNOWBALL_ORDINAL_RANGE": "18446744073709551615",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SNOWBALL_ORDINAL_PATTERN", "SNOWBALL_ORDINAL_OFFSET", "SNOWBALL_ORDINAL_RANGE"} {
+				t.Setenv(key, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			provider, err := NewOrdinalServerIDProviderFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewOrdinalServerIDProviderFromEnv() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			id, err := provider.ServerId()
+			if err != nil {
+				t.Fatalf("Error occurred running ServerId: %s", err)
+			}
+			if id != tt.want {
+				t.Errorf("ServerId() = %v, want: %v", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitNodeWithOrdinal(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("HOSTNAME", "snowball-9")
+	t.Setenv("SNOWBALL_ORDINAL_OFFSET", "100")
+	t.Setenv("SNOWBALL_SERVER_ID_SOURCES", "ordinal,env")
+
+	node, err := InitNode(false)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if node.ServerId() != 109 || node.ServerIdSource() != "ordinal" {
+		t.Errorf("InitNode() = %v from %q, want: %v from %q", node.ServerId(), node.ServerIdSource(), 109, "ordinal")
+	}
+}
@@ -0,0 +1,81 @@
+package snowball
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// The sections of a Snowball ID, along with the time it was generated.
+type Components struct {
+	// The time the ID was generated, at millisecond precision.
+	Time time.Time
+	// Milliseconds elapsed between the epoch and the time the ID was generated.
+	Timestamp uint64
+	ServerId  uint64
+	Sequence  uint64
+}
+
+// Unpacks a Snowball ID generated with the given epoch into its sections.
+func Decompose(id SnowballID, epoch time.Time) Components {
+	timestamp, serverId, sequence := DefaultLayout.Decompose(id)
+	return Components{
+		Time:      epoch.Add(time.Duration(timestamp) * time.Millisecond),
+		Timestamp: timestamp,
+		ServerId:  serverId,
+		Sequence:  sequence,
+	}
+}
+
+// Unpacks a Snowball ID generated with the node's epoch into its sections.
+func (node *SnowballNode) Decompose(id SnowballID) Components {
+	return Decompose(id, node.Epoch())
+}
+
+// Converts a string in any supported encoding into a Snowball ID, returning the encoding that was detected.
+//
+// Several encodings share an alphabet, so detection follows a fixed order:
+//   - UUIDs are recognized by their 8-4-4-4-12 form
+//   - base32 and base64 are recognized by their padding ("===" and "=" respectively)
+//   - strings prefixed by "0x" or "0b" are read as hex or binary
+//   - strings of decimal digits are read as decimal
+//   - strings of hex digits are read as hex
+//   - anything else is read as base62
+//
+// Binary strings without the "0b" prefix are therefore read as decimal. Use Decode when the encoding is known.
+func ParseID(sid string) (SnowballID, Encoding, error) {
+	enc, sid := detectEncoding(sid)
+	if enc == "" {
+		return 0, "", errors.New("decode failed: string is empty")
+	}
+
+	id, err := Decode(enc, sid)
+	if err != nil {
+		return 0, enc, err
+	}
+	return id, enc, nil
+}
+
+// Determines which encoding ParseID should decode the string with, stripping any prefix.
+func detectEncoding(sid string) (Encoding, string) {
+	switch {
+	case sid == "":
+		return "", sid
+	case len(sid) == 36 && strings.Count(sid, "-") == 4:
+		return EncodingUUID, sid
+	case len(sid) == 16 && strings.HasSuffix(sid, "==="):
+		return EncodingBase32, sid
+	case len(sid) == 12 && strings.HasSuffix(sid, "="):
+		return EncodingBase64, sid
+	case strings.HasPrefix(sid, "0x") || strings.HasPrefix(sid, "0X"):
+		return EncodingHex, sid[2:]
+	case strings.HasPrefix(sid, "0b") || strings.HasPrefix(sid, "0B"):
+		return EncodingBinary, sid[2:]
+	case strings.Trim(sid, "0123456789") == "":
+		return EncodingDecimal, sid
+	case strings.Trim(sid, "0123456789abcdefABCDEF") == "":
+		return EncodingHex, sid
+	}
+
+	return EncodingBase62, sid
+}
@@ -0,0 +1,88 @@
+package snowball
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    SnowballID
+		wantEnc Encoding
+		wantErr bool
+	}{
+		{name: "Decimal", arg: "19638199173316608", want: 19638199173316608, wantEnc: EncodingDecimal},
+		{name: "Hex", arg: "45c4d68dc10000", want: 19638199173316608, wantEnc: EncodingHex},
+		{name: "Prefixed hex", arg: "0x45c4d68dc10000", want: 19638199173316608, wantEnc: EncodingHex},
+		{
+			name:    "Prefixed binary",
+			arg:     "0b1000101110001001101011010001101110000010000000000000000",
+			want:    19638199173316608,
+			wantEnc: EncodingBinary,
+		},
+		{name: "Base32", arg: "AAAMDDOWYRCQA===", want: 19638199173316608, wantEnc: EncodingBase32},
+		{name: "Base62", arg: "1RwTVZKtLU", want: 19638199173316608, wantEnc: EncodingBase62},
+		{name: "Base64", arg: "AADBjdbERQA=", want: 19638199173316608, wantEnc: EncodingBase64},
+		{
+			name:    "UUID",
+			arg:     "0045c4d6-8dc1-8000-8000-000000000000",
+			want:    19638199173316608,
+			wantEnc: EncodingUUID,
+		},
+		{name: "Empty string", arg: "", want: 0, wantErr: true},
+		{name: "Invalid characters", arg: "1RwT+EjcUi", want: 0, wantEnc: EncodingBase62, wantErr: true},
+		{name: "Overflowing base62", arg: "zzzzzzzzzzzzzzzzzzzzzzzzzz", want: 0, wantEnc: EncodingBase62, wantErr: true},
+		{name: "Truncated base32", arg: "AAAAAAAAAAAA====", want: 0, wantEnc: EncodingBase32, wantErr: true},
+		{name: "Truncated base64", arg: "AAAAAAAAAA==", want: 0, wantEnc: EncodingBase64, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, enc, err := ParseID(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseID() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if id != tt.want {
+				t.Errorf("ParseID() decoded: %v, want: %v", id, tt.want)
+			}
+			if enc != tt.wantEnc {
+				t.Errorf("ParseID() encoding: %v, want: %v", enc, tt.wantEnc)
+			}
+		})
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	epoch := time.UnixMilli(1704121810000)
+	got := Decompose(19638199173316608+5, epoch)
+	want := Components{
+		Time:      time.UnixMilli(1704121810000 + 4682111543),
+		Timestamp: 4682111543,
+		ServerId:  32,
+		Sequence:  5,
+	}
+	if !got.Time.Equal(want.Time) || got.Timestamp != want.Timestamp || got.ServerId != want.ServerId ||
+		got.Sequence != want.Sequence {
+		t.Errorf("Decompose() decoded: %+v, want: %+v", got, want)
+	}
+}
+
+func TestNodeDecompose(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	before := time.Now().Truncate(time.Millisecond)
+	id := node.GenerateID()
+	after := time.Now()
+
+	got := node.Decompose(id)
+	if got.ServerId != 32 {
+		t.Errorf("Decompose() server ID: %v, want: 32", got.ServerId)
+	}
+	if got.Time.Before(before.Add(-time.Millisecond)) || got.Time.After(after.Add(time.Millisecond)) {
+		t.Errorf("Decompose() time: %v, want between %v and %v", got.Time, before, after)
+	}
+}
@@ -0,0 +1,295 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Returned (possibly wrapped) by a ServerIDProvider whose source of server IDs is not available, e.g. because
+// it is not configured. FirstOf moves on to the next provider when it sees this error; any other error is
+// treated as a misconfiguration and stops the search.
+var ErrServerIdUnavailable = errors.New("server ID not available")
+
+// An error describing why a provider's source is not available, which matches ErrServerIdUnavailable.
+type unavailableError string
+
+func (e unavailableError) Error() string {
+	return string(e)
+}
+
+func (e unavailableError) Is(target error) bool {
+	return target == ErrServerIdUnavailable
+}
+
+// Supplies the server ID for a SnowballNode.
+type ServerIDProvider interface {
+	// Returns the server ID, or an error if it cannot be determined.
+	ServerId() (uint64, error)
+	// Returns a short name for the provider's source, e.g. "env" or "ip", used in configuration and logs.
+	String() string
+}
+
+// Configures the node to take its server ID from the given provider rather than from the environment.
+func WithServerIDProvider(p ServerIDProvider) Option {
+	return func(node *SnowballNode) {
+		node.provider = p
+	}
+}
+
+// Provides a fixed server ID.
+type StaticServerID uint64
+
+func (id StaticServerID) ServerId() (uint64, error) {
+	return uint64(id), nil
+}
+
+func (id StaticServerID) String() string {
+	return "static"
+}
+
+// Provides the server ID from the SNOWBALL_NODE_ID environment variable.
+type EnvServerIDProvider struct{}
+
+func (EnvServerIDProvider) ServerId() (uint64, error) {
+	serverId, err := GetenvInteger("SNOWBALL_NODE_ID")
+	if errors.Is(err, ErrEmptyEnvVar) {
+		return 0, unavailableError("SNOWBALL_NODE_ID is not set")
+	} else if err != nil {
+		return 0, fmt.Errorf("get server id failed: SNOWBALL_NODE_ID: %w", err)
+	}
+
+	return serverId, nil
+}
+
+func (EnvServerIDProvider) String() string {
+	return "env"
+}
+
+// Provides the server ID from the IP address in the SERVER_IP_ADDRESS environment variable. See
+// GetServerIdFromIPAddress.
+type IPAddressServerIDProvider struct{}
+
+func (IPAddressServerIDProvider) ServerId() (uint64, error) {
+	serverId, err := GetServerIdFromIPAddress()
+	if errors.Is(err, ErrEmptyEnvVar) {
+		return 0, unavailableError("SERVER_IP_ADDRESS is not set")
+	}
+
+	return serverId, err
+}
+
+func (IPAddressServerIDProvider) String() string {
+	return "ip"
+}
+
+// Provides the server ID from the address of the host's primary network interface, for deployments where
+// the address is not injected into the environment.
+//
+// The first interface that is up and not a loopback interface is used, preferring IPv4 addresses over IPv6
+// and ignoring loopback and link-local addresses. The address is mapped onto a server ID using Mapping.
+type InterfaceServerIDProvider struct {
+	// If set, only the interface with this name is considered.
+	Interface string
+	// If set, only addresses within this network are considered.
+	Network *net.IPNet
+	// How the selected address is mapped onto a server ID.
+	Mapping IPMapping
+}
+
+// Creates a provider configured from the environment: SNOWBALL_INTERFACE restricts detection to the named
+// interface, SNOWBALL_INTERFACE_CIDR restricts it to addresses in a network, and SNOWBALL_IP_BITS and
+// SNOWBALL_IP_CIDR configure the mapping as for GetServerIdFromIPAddress.
+func NewInterfaceServerIDProviderFromEnv() (*InterfaceServerIDProvider, error) {
+	mapping, err := getIPMapping()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InterfaceServerIDProvider{Mapping: mapping}
+	result.Interface, _ = GetenvStr("SNOWBALL_INTERFACE")
+	if cidr, err := GetenvStr("SNOWBALL_INTERFACE_CIDR"); err == nil {
+		if _, result.Network, err = net.ParseCIDR(cidr); err != nil {
+			return nil, fmt.Errorf("get server id failed: SNOWBALL_INTERFACE_CIDR: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func (p *InterfaceServerIDProvider) ServerId() (uint64, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return 0, fmt.Errorf("get server id failed: listing network interfaces: %w", err)
+	}
+
+	var candidates []interfaceAddrs
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, interfaceAddrs{name: iface.Name, addrs: addrs})
+	}
+
+	ip, err := p.selectAddress(candidates)
+	if err != nil {
+		return 0, err
+	}
+	return ServerIdFromIP(ip, p.Mapping)
+}
+
+func (p *InterfaceServerIDProvider) String() string {
+	return "interface"
+}
+
+// The addresses assigned to a network interface.
+type interfaceAddrs struct {
+	name  string
+	addrs []net.Addr
+}
+
+// Picks the address to derive the server ID from, honoring the provider's interface and network filters.
+func (p *InterfaceServerIDProvider) selectAddress(candidates []interfaceAddrs) (net.IP, error) {
+	var fallback net.IP
+	for _, iface := range candidates {
+		if p.Interface != "" && iface.name != p.Interface {
+			continue
+		}
+
+		for _, addr := range iface.addrs {
+			var ip net.IP
+			switch a := addr.(type) {
+			case *net.IPNet:
+				ip = a.IP
+			case *net.IPAddr:
+				ip = a.IP
+			}
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || !ip.IsGlobalUnicast() {
+				continue
+			}
+			if p.Network != nil && !p.Network.Contains(ip) {
+				continue
+			}
+
+			if ip.To4() != nil {
+				return ip, nil
+			}
+			if fallback == nil {
+				fallback = ip
+			}
+		}
+	}
+
+	if fallback == nil {
+		return nil, unavailableError("no suitable interface address found" + p.describeFilters())
+	}
+	return fallback, nil
+}
+
+func (p *InterfaceServerIDProvider) describeFilters() string {
+	var filters []string
+	if p.Interface != "" {
+		filters = append(filters, "interface "+p.Interface)
+	}
+	if p.Network != nil {
+		filters = append(filters, "network "+p.Network.String())
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	return " (restricted to " + strings.Join(filters, ", ") + ")"
+}
+
+// Tries each provider in order, returning the server ID from the first one that is available.
+type chainProvider []ServerIDProvider
+
+// Creates a provider that tries each of the given providers in order. A provider failing with
+// ErrServerIdUnavailable is skipped; any other error is returned immediately. If no provider is available,
+// the returned error lists why each one was skipped.
+func FirstOf(providers ...ServerIDProvider) ServerIDProvider {
+	return chainProvider(providers)
+}
+
+func (c chainProvider) ServerId() (uint64, error) {
+	id, _, err := c.resolve()
+	return id, err
+}
+
+// Returns the server ID along with the provider that supplied it.
+func (c chainProvider) resolve() (uint64, ServerIDProvider, error) {
+	var reasons []string
+	for _, p := range c {
+		id, err := p.ServerId()
+		if err == nil {
+			return id, p, nil
+		}
+		if !errors.Is(err, ErrServerIdUnavailable) {
+			return 0, nil, err
+		}
+		reasons = append(reasons, p.String()+": "+err.Error())
+	}
+
+	return 0, nil, unavailableError(
+		"get server id failed: no server ID source available (" + strings.Join(reasons, "; ") + ")",
+	)
+}
+
+func (c chainProvider) String() string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// Builds a provider from a comma-separated list of source names, tried in order: "env" (SNOWBALL_NODE_ID),
+// "ip" (SERVER_IP_ADDRESS), "interface" (auto-detected from the host's network interfaces) and "ordinal" (the
+// StatefulSet ordinal in the hostname, see OrdinalServerIDProvider).
+func ParseServerIDSources(sources string) (ServerIDProvider, error) {
+	var providers []ServerIDProvider
+	for _, name := range strings.Split(sources, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "env":
+			providers = append(providers, EnvServerIDProvider{})
+		case "ip":
+			providers = append(providers, IPAddressServerIDProvider{})
+		case "interface":
+			p, err := NewInterfaceServerIDProviderFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, p)
+		case "ordinal":
+			p, err := NewOrdinalServerIDProviderFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			providers = append(providers, p)
+		default:
+			return nil, fmt.Errorf("get server id failed: unknown server ID source %q", name)
+		}
+	}
+
+	return FirstOf(providers...), nil
+}
+
+// Returns the server ID from the provider, along with the name of the source that supplied it.
+func resolveServerId(p ServerIDProvider) (uint64, string, error) {
+	if chain, ok := p.(chainProvider); ok {
+		id, source, err := chain.resolve()
+		if err != nil {
+			return 0, "", err
+		}
+		return id, source.String(), nil
+	}
+
+	id, err := p.ServerId()
+	if err != nil {
+		return 0, "", err
+	}
+	return id, p.String(), nil
+}
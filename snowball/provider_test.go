@@ -0,0 +1,171 @@
+package snowball
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// Provides an error, for exercising FirstOf.
+type failingProvider struct {
+	err error
+}
+
+func (p failingProvider) ServerId() (uint64, error) {
+	return 0, p.err
+}
+
+func (p failingProvider) String() string {
+	return "failing"
+}
+
+func TestFirstOf(t *testing.T) {
+	misconfigured := errors.New("misconfigured")
+	tests := []struct {
+		name       string
+		arg        []ServerIDProvider
+		want       uint64
+		wantSource string
+		wantErr    error
+	}{
+		{
+			name:       "First available",
+			arg:        []ServerIDProvider{StaticServerID(7), StaticServerID(8)},
+			want:       7,
+			wantSource: "static",
+		},
+		{
+			name:       "Skips unavailable",
+			arg:        []ServerIDProvider{failingProvider{unavailableError("unset")}, StaticServerID(8)},
+			want:       8,
+			wantSource: "static",
+		},
+		{
+			name:    "Stops at misconfiguration",
+			arg:     []ServerIDProvider{failingProvider{misconfigured}, StaticServerID(8)},
+			wantErr: misconfigured,
+		},
+		{
+			name:    "None available",
+			arg:     []ServerIDProvider{failingProvider{unavailableError("unset")}},
+			wantErr: ErrServerIdUnavailable,
+		},
+		{name: "Empty", wantErr: ErrServerIdUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, source, err := resolveServerId(FirstOf(tt.arg...))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FirstOf() error: %v, want: %v", err, tt.wantErr)
+			}
+			if id != tt.want || source != tt.wantSource {
+				t.Errorf("FirstOf() = %v from %q, want: %v from %q", id, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestSelectAddress(t *testing.T) {
+	ipNet := func(cidr string) net.Addr {
+		ip, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		network.IP = ip
+		return network
+	}
+	candidates := []interfaceAddrs{
+		{name: "eth0", addrs: []net.Addr{ipNet("fe80::1/64"), ipNet("2001:db8::5/64"), ipNet("10.1.2.3/16")}},
+		{name: "eth1", addrs: []net.Addr{ipNet("192.168.0.9/24")}},
+		{name: "eth2", addrs: []net.Addr{ipNet("169.254.0.1/16"), ipNet("2001:db8:1::7/64")}},
+	}
+
+	tests := []struct {
+		name    string
+		arg     InterfaceServerIDProvider
+		want    string
+		wantErr bool
+	}{
+		{name: "Prefers IPv4", arg: InterfaceServerIDProvider{}, want: "10.1.2.3"},
+		{name: "Interface", arg: InterfaceServerIDProvider{Interface: "eth1"}, want: "192.168.0.9"},
+		{name: "IPv6 only", arg: InterfaceServerIDProvider{Interface: "eth2"}, want: "2001:db8:1::7"},
+		{name: "Network", arg: InterfaceServerIDProvider{Network: mustParseCIDR("192.168.0.0/16")}, want: "192.168.0.9"},
+		{
+			name: "Interface and network",
+			arg:  InterfaceServerIDProvider{Interface: "eth2", Network: mustParseCIDR("2001:db8:1::/48")},
+			want: "2001:db8:1::7",
+		},
+		{name: "Unknown interface", arg: InterfaceServerIDProvider{Interface: "wlan0"}, wantErr: true},
+		{name: "No match", arg: InterfaceServerIDProvider{Network: mustParseCIDR("172.16.0.0/12")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := tt.arg.selectAddress(candidates)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectAddress() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrServerIdUnavailable) {
+					t.Errorf("selectAddress() error: %v, want: %v", err, ErrServerIdUnavailable)
+				}
+				return
+			}
+			if !ip.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("selectAddress() = %v, want: %v", ip, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseServerIDSources(t *testing.T) {
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+	t.Setenv("SERVER_IP_ADDRESS", "")
+
+	provider, err := ParseServerIDSources("ip, env")
+	if err != nil {
+		t.Fatalf("Error occurred running ParseServerIDSources: %s", err)
+	}
+	id, source, err := resolveServerId(provider)
+	if err != nil {
+		t.Fatalf("Error occurred resolving server ID: %s", err)
+	}
+	if id != 32 || source != "env" {
+		t.Errorf("ParseServerIDSources() = %v from %q, want: %v from %q", id, source, 32, "env")
+	}
+
+	if _, err := ParseServerIDSources("env,dns"); err == nil {
+		t.Errorf("No error occurred running ParseServerIDSources with an unknown source")
+	}
+}
+
+func TestInitNodeServerIdSources(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "")
+	t.Setenv("SERVER_IP_ADDRESS", "")
+
+	// A configured source that yields nothing is an error rather than a silent default of 0.
+	t.Setenv("SNOWBALL_SERVER_ID_SOURCES", "env")
+	if _, err := InitNode(false); !errors.Is(err, ErrServerIdUnavailable) {
+		t.Errorf("InitNode() error: %v, want: %v", err, ErrServerIdUnavailable)
+	}
+
+	t.Setenv("SERVER_IP_ADDRESS", "10.1.2.3")
+	t.Setenv("SNOWBALL_SERVER_ID_SOURCES", "env,ip")
+	node, err := InitNode(false)
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if node.ServerId() != 0x203 || node.ServerIdSource() != "ip" {
+		t.Errorf("InitNode() = %v from %q, want: %v from %q", node.ServerId(), node.ServerIdSource(), 0x203, "ip")
+	}
+
+	node, err = InitNode(false, WithServerIDProvider(StaticServerID(5)))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if node.ServerId() != 5 || node.Ready() != nil {
+		t.Errorf("InitNode() server ID: %v, want: %v", node.ServerId(), 5)
+	}
+}
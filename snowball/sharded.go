@@ -0,0 +1,119 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Generates IDs from several inner nodes, each with its own server ID and lock, for processes that need more
+// than the 2048 IDs per millisecond a single node can issue. Requests are distributed round-robin across the
+// shards, giving roughly one node's throughput per shard when callers are concurrent.
+//
+// The shards own a contiguous block of server IDs starting at the server ID the node would otherwise have been
+// given, so the whole block must be reserved for the process. IDs are unique across shards, but unlike IDs from a
+// single node, IDs from different calls are only ordered by their timestamps.
+type ShardedNode struct {
+	shards []*SnowballNode
+	next   atomic.Uint64
+}
+
+// Creates and returns a sharded node with the given number of shards. The first shard's server ID is resolved as
+// by InitNode, and the other shards take the server IDs that follow it, all of which must be at most
+// MaxServerId. All shards share the first shard's epoch and the given options.
+func InitShardedNode(useIp bool, shards int, opts ...Option) (*ShardedNode, error) {
+	if shards <= 0 {
+		return nil, errors.New("initialization failed: number of shards must be positive")
+	}
+
+	first, err := InitNode(useIp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if last := first.serverId + uint64(shards) - 1; last > uint64(MaxServerId) {
+		return nil, fmt.Errorf("initialization failed: %d shards starting at server ID %d exceed the maximum server ID %d",
+			shards, first.serverId, MaxServerId)
+	}
+
+	result := &ShardedNode{shards: make([]*SnowballNode, shards)}
+	result.shards[0] = first
+	for i := 1; i < shards; i++ {
+		shardOpts := append(slices.Clip(opts),
+			WithEpoch(first.Epoch()),
+			WithServerIDProvider(StaticServerID(first.serverId+uint64(i))),
+		)
+		if result.shards[i], err = InitNode(false, shardOpts...); err != nil {
+			return nil, err
+		}
+		// The block is only as assigned as the server ID it starts at.
+		result.shards[i].assigned = first.assigned
+	}
+	return result, nil
+}
+
+// Returns the shard to serve the next request.
+func (s *ShardedNode) shard() *SnowballNode {
+	return s.shards[(s.next.Add(1)-1)%uint64(len(s.shards))]
+}
+
+// Creates and returns a new, unique Snowball ID. Returns 0 if the node is suspended; use NextID to tell this
+// apart from a valid ID.
+func (s *ShardedNode) GenerateID() SnowballID {
+	return s.shard().GenerateID()
+}
+
+// Creates and returns count new, unique Snowball IDs in increasing order, all from a single shard. Returns an
+// empty slice if count is not positive or the node is suspended; use NextIDs to tell these apart.
+func (s *ShardedNode) GenerateIDs(count int) []SnowballID {
+	return s.shard().GenerateIDs(count)
+}
+
+// Creates and returns a new, unique Snowball ID, or an error wrapping ErrSuspended if the node is suspended.
+func (s *ShardedNode) NextID() (SnowballID, error) {
+	return s.shard().NextID()
+}
+
+// Like GenerateIDs, but returns an error wrapping ErrSuspended if the node is suspended.
+func (s *ShardedNode) NextIDs(count int) ([]SnowballID, error) {
+	return s.shard().NextIDs(count)
+}
+
+// Suspends every shard until the returned function is called. See SnowballNode.Suspend.
+func (s *ShardedNode) Suspend(reason error) (resume func()) {
+	resumes := make([]func(), len(s.shards))
+	for i, shard := range s.shards {
+		resumes[i] = shard.Suspend(reason)
+	}
+	return func() {
+		for _, resume := range resumes {
+			resume()
+		}
+	}
+}
+
+// Reports whether every shard is ready to issue IDs. See SnowballNode.Ready.
+func (s *ShardedNode) Ready() error {
+	for _, shard := range s.shards {
+		if err := shard.Ready(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the shards, in order of server ID.
+func (s *ShardedNode) Shards() []*SnowballNode {
+	return slices.Clone(s.shards)
+}
+
+// Returns the epoch that timestamps in IDs generated by the node are relative to.
+func (s *ShardedNode) Epoch() time.Time {
+	return s.shards[0].Epoch()
+}
+
+// Returns the first and last server IDs of the block owned by the node.
+func (s *ShardedNode) ServerIds() (first, last uint64) {
+	return s.shards[0].serverId, s.shards[len(s.shards)-1].serverId
+}
@@ -0,0 +1,115 @@
+package snowball
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestInitShardedNode(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, err := InitShardedNode(false, 4)
+	if err != nil {
+		t.Fatalf("Error occurred running InitShardedNode: %s", err)
+	}
+	if first, last := node.ServerIds(); first != 32 || last != 35 {
+		t.Errorf("ServerIds() = %d, %d, want: 32, 35", first, last)
+	}
+	for _, shard := range node.Shards() {
+		if !shard.Epoch().Equal(node.Epoch()) {
+			t.Errorf("Shard %d epoch: %s, want: %s", shard.ServerId(), shard.Epoch(), node.Epoch())
+		}
+	}
+
+	resume := node.Suspend(nil)
+	for i := 0; i < 4; i++ {
+		if _, err := node.NextID(); !errors.Is(err, ErrSuspended) {
+			t.Errorf("NextID() error while suspended: %v, want: %v", err, ErrSuspended)
+		}
+	}
+	resume()
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() after resuming: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		shards int
+		nodeId string
+	}{
+		{name: "No shards", shards: 0, nodeId: "32"},
+		{name: "Block exceeds maximum server ID", shards: 2, nodeId: "2047"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SNOWBALL_NODE_ID", tt.nodeId)
+			if _, err := InitShardedNode(false, tt.shards); err == nil {
+				t.Errorf("No error occurred running InitShardedNode")
+			}
+		})
+	}
+}
+
+func TestShardedNodeConcurrent(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitShardedNode(false, 4)
+	results := make([][]SnowballID, 16)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				results[i] = append(results[i], node.GenerateID())
+			}
+			results[i] = append(results[i], node.GenerateIDs(5000)...)
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[SnowballID]bool)
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("ID %d was issued more than once", id)
+			}
+			seen[id] = true
+		}
+	}
+	if len(seen) != 16*15000 {
+		t.Errorf("Generated %d unique IDs, want: %d", len(seen), 16*15000)
+	}
+}
+
+func BenchmarkShardedNodeGenerateID(b *testing.B) {
+	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	b.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitShardedNode(false, 8)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = node.GenerateID()
+		}
+	})
+}
+
+func BenchmarkGenerateIDParallel(b *testing.B) {
+	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	b.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = node.GenerateID()
+		}
+	})
+}
@@ -2,11 +2,24 @@ package snowball
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var (
+	ErrClockBehind        = errors.New("node not ready: clock is behind the last issued timestamp")
+	ErrServerIdUnassigned = errors.New("node not ready: server ID was not assigned, using default")
+	ErrSuspended          = errors.New("node not ready: ID generation is suspended")
+	ErrPartitioned        = errors.New("node partitioned: IDs must be generated with its TypeGenerators")
+	ErrTimestampExhausted = errors.New("node not ready: timestamp section is exhausted, choose a later epoch")
+)
+
 const (
 	// Length of the timestamp section.
 	TimestampLen uint8 = 42
@@ -33,28 +46,143 @@ type SnowballNode struct {
 	mutex sync.Mutex
 	epoch time.Time
 
-	serverId uint64
-	currTime uint64
-	currSeq  uint64
+	serverId       uint64
+	serverIdSource string
+	assigned       bool
+	provider       ServerIDProvider
+	strictEnv      bool
+	namespace      string
+	randomSequence bool
+	layout         Layout
+	state          *persistedState
+
+	sequencer
+	// Reasons the node is suspended, oldest first. IDs are only issued while there are none.
+	suspensions []*suspension
+	// The oldest suspension, or nil, for reading without the node's lock.
+	suspendedBy atomic.Pointer[suspension]
+	// Set once the node's sequence section has been partitioned by TypeGenerators.
+	typed bool
+
+	metricsCollector *Metrics
+	metrics          *nodeMetrics
+	logger           *slog.Logger
+}
+
+// Configures optional behavior of a SnowballNode created by InitNode.
+type Option func(*SnowballNode)
+
+// Records metrics about the IDs generated by the node in the given collector.
+func WithMetrics(m *Metrics) Option {
+	return func(node *SnowballNode) {
+		node.metricsCollector = m
+	}
+}
+
+// Sets the node's epoch rather than reading it from the SNOWBALL_EPOCH_MS environment variable.
+func WithEpoch(epoch time.Time) Option {
+	return func(node *SnowballNode) {
+		node.epoch = epoch
+	}
+}
+
+// Names the namespace the node generates IDs for, when several nodes with different epochs or server IDs are
+// hosted in one process. The name labels the node's metrics and log records.
+func WithNamespace(name string) Option {
+	return func(node *SnowballNode) {
+		node.namespace = name
+	}
+}
+
+// Starts the sequence of each millisecond at a random number rather than 0, so the low bits of IDs are evenly
+// distributed even when few IDs are issued per millisecond, e.g. for sharding rows by ID modulo the number of
+// shards. IDs stay increasing: the sequence starts in the lower half of its range and the node waits for the next
+// millisecond when it is exhausted, as usual, so at least half the usual number of IDs can be issued per
+// millisecond.
+func WithRandomSequenceStart() Option {
+	return func(node *SnowballNode) {
+		node.randomSequence = true
+	}
+}
+
+// Keeps the top bit of IDs zero, so they never exceed math.MaxInt64 and can be stored and read as signed 64-bit
+// integers, e.g. by Java clients or in a Postgres BIGINT column. IDs use SignedLayout, whose timestamps run out
+// about 69 years after the epoch, and InitNode fails if that time has already passed.
+func WithSignedSafe() Option {
+	return func(node *SnowballNode) {
+		node.layout = SignedLayout
+	}
+}
+
+// Makes InitNode fail, with an error naming the variable, if SNOWBALL_EPOCH_MS is malformed or if no server ID
+// provider is configured and SNOWBALL_NODE_ID is missing or malformed. By default the node falls back to the
+// default epoch and server ID 0 instead, which risks colliding with other nodes.
+func WithStrictEnv() Option {
+	return func(node *SnowballNode) {
+		node.strictEnv = true
+	}
 }
 
 // Creates and returns a new node object for generating Snowball IDs
-func InitNode(useIp bool) (*SnowballNode, error) {
+func InitNode(useIp bool, opts ...Option) (*SnowballNode, error) {
 	if SequenceLen+ServerIdLen > 22 {
 		return nil, errors.New("initialization failed: sequence and server ID length is invalid")
 	}
 
-	var ServerId uint64
-	if useIp {
-		ServerId = GetServerIdFromIPAddress()
+	result := SnowballNode{layout: DefaultLayout}
+	for _, opt := range opts {
+		opt(&result)
+	}
+
+	provider := result.provider
+	if provider == nil {
+		if sources, err := GetenvStr("SNOWBALL_SERVER_ID_SOURCES"); err == nil {
+			if provider, err = ParseServerIDSources(sources); err != nil {
+				return nil, err
+			}
+		} else if useIp {
+			iface, err := NewInterfaceServerIDProviderFromEnv()
+			if err != nil {
+				return nil, err
+			}
+			provider = FirstOf(IPAddressServerIDProvider{}, iface)
+		}
+	}
+
+	if provider != nil {
+		// An explicitly configured source must yield a server ID; defaulting to 0 would risk collisions.
+		serverId, source, err := resolveServerId(provider)
+		if err != nil {
+			return nil, err
+		}
+		result.serverId, result.serverIdSource, result.assigned = serverId, source, true
+	} else if result.strictEnv {
+		serverId, err := LookupServerId()
+		if err != nil {
+			return nil, fmt.Errorf("initialization failed: %w", err)
+		}
+		result.serverId, result.serverIdSource, result.assigned = serverId, "env", true
 	} else {
-		ServerId = GetServerId()
+		serverId, err := lookupServerId()
+		result.serverId, result.assigned = serverId, err == nil
+		result.serverIdSource = "env"
+		if err != nil {
+			result.serverIdSource = "default"
+		}
+	}
+	var EpochTime time.Time = result.epoch
+	if EpochTime.IsZero() {
+		// Even in strict mode a missing epoch is fine, as the default is well known, but a malformed one is not.
+		// An implausible one is always fatal: it was deliberately configured, so neither it nor the default can
+		// be trusted to keep IDs increasing.
+		if _, err := LookupEpoch(); err != nil &&
+			(errors.Is(err, ErrImplausibleEpoch) || result.strictEnv && !errors.Is(err, ErrEmptyEnvVar)) {
+			return nil, fmt.Errorf("initialization failed: %w", err)
+		}
+		var Epoch uint64 = GetEpoch()
+		EpochTime = time.Unix(int64(Epoch)/1000, (int64(Epoch)%1000)*1000000)
 	}
-	var Epoch uint64 = GetEpoch()
-	var EpochTime time.Time = time.Unix(int64(Epoch)/1000, (int64(Epoch)%1000)*1000000)
 
-	result := SnowballNode{}
-	result.serverId = ServerId
 	if result.serverId > uint64(MaxServerId) {
 		return nil, errors.New(
 			"initialization failed: server ID must be between 0 and " + strconv.FormatInt(int64(MaxServerId), 10),
@@ -64,30 +192,277 @@ func InitNode(useIp bool) (*SnowballNode, error) {
 	// Setting the epoch like this ensures we have a monotonic clock (i.e., NTP and Daylight Saving Time won't
 	// impact time computation)
 	var now = time.Now()
+	if err := result.layout.CheckEpoch(EpochTime, now); err != nil {
+		return nil, fmt.Errorf("initialization failed: %w", err)
+	}
 	result.epoch = now.Add(EpochTime.Sub(now))
+	if result.state != nil {
+		if err := result.restoreState(); err != nil {
+			return nil, fmt.Errorf("initialization failed: %w", err)
+		}
+	}
+	if result.metricsCollector != nil {
+		result.metrics = result.metricsCollector.forNode(result.serverId, result.namespace)
+	}
+	result.log().Info("node initialized",
+		slog.Uint64("server_id", result.serverId),
+		slog.String("server_id_source", result.serverIdSource),
+		slog.Time("epoch", result.Epoch()),
+		slog.Time("valid_until", result.ValidUntil()),
+	)
+	if remaining := result.ValidUntil().Sub(now); remaining < epochExhaustionWarning {
+		result.log().Warn("IDs will soon run out of timestamps, choose a later epoch",
+			slog.Time("valid_until", result.ValidUntil()))
+	}
+	if crossing, ok := result.layout.SignBitCrossing(result.Epoch()); ok && crossing.Sub(now) < epochExhaustionWarning {
+		// Unsigned clients are fine, but signed ones will read the IDs as negative numbers.
+		result.log().Warn("IDs exceed or will soon exceed the maximum signed 64-bit integer, "+
+			"choose a later epoch or use signed-safe IDs if any client reads them as signed",
+			slog.Time("sign_bit_crossing", crossing))
+	}
 	return &result, nil
 }
 
-// Creates and returns a new, unique Snowball ID.
+// Creates and returns a new, unique Snowball ID. Returns 0 if the node is suspended; use NextID to tell
+// this apart from a valid ID.
 func (node *SnowballNode) GenerateID() SnowballID {
+	id, _ := node.NextID()
+	return id
+}
+
+// Creates and returns count new, unique Snowball IDs in increasing order. The IDs are reserved under a single
+// acquisition of the node's lock, so concurrent callers cannot interleave with the batch. Returns an empty
+// slice if count is not positive or the node is suspended; use NextIDs to tell these apart.
+func (node *SnowballNode) GenerateIDs(count int) []SnowballID {
+	result, err := node.NextIDs(count)
+	if err != nil {
+		return []SnowballID{}
+	}
+	return result
+}
+
+// Creates and returns a new, unique Snowball ID, or an error wrapping ErrSuspended if the node is suspended.
+// Returns ErrPartitioned if the node's sequence has been partitioned with TypeGenerators, and
+// ErrTimestampExhausted once the node's timestamps have run out (see ValidUntil).
+func (node *SnowballNode) NextID() (SnowballID, error) {
+	start := time.Now()
+	node.mutex.Lock()
+	if err := node.unavailable(); err != nil {
+		node.mutex.Unlock()
+		return 0, err
+	}
+	result, err := node.nextID()
+	node.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if node.metrics != nil {
+		node.observe(start, 1)
+	}
+	return result, nil
+}
+
+// Like GenerateIDs, but returns an error wrapping ErrSuspended if the node is suspended, or ErrPartitioned if
+// its sequence has been partitioned with TypeGenerators.
+func (node *SnowballNode) NextIDs(count int) ([]SnowballID, error) {
+	if count <= 0 {
+		return []SnowballID{}, nil
+	}
+
+	start := time.Now()
+	result := make([]SnowballID, count)
+	node.mutex.Lock()
+	if err := node.unavailable(); err != nil {
+		node.mutex.Unlock()
+		return nil, err
+	}
+	for i := range result {
+		var err error
+		if result[i], err = node.nextID(); err != nil {
+			node.mutex.Unlock()
+			return nil, err
+		}
+	}
+	node.mutex.Unlock()
+
+	if node.metrics != nil {
+		node.observe(start, count)
+	}
+	return result, nil
+}
+
+// A reason for a node to be suspended.
+type suspension struct {
+	err error
+}
+
+// Stops the node from issuing IDs, e.g. because the node can no longer be sure its server ID is unique, until
+// the returned function is called. While suspended, NextID and NextIDs return an error wrapping both
+// ErrSuspended and reason, and Ready reports the same error.
+//
+// Suspensions for different reasons are independent: the node only resumes once the functions returned for
+// all of them have been called. Calling a function more than once has no further effect.
+func (node *SnowballNode) Suspend(reason error) (resume func()) {
+	s := &suspension{err: ErrSuspended}
+	if reason != nil {
+		s.err = fmt.Errorf("%w: %w", ErrSuspended, reason)
+	}
+
 	node.mutex.Lock()
 	defer node.mutex.Unlock()
+	node.suspensions = append(node.suspensions, s)
+	node.suspendedBy.Store(node.suspensions[0])
+	node.log().Warn("ID generation suspended", slog.Any("reason", s.err))
+
+	return func() {
+		node.mutex.Lock()
+		defer node.mutex.Unlock()
+		if !slices.Contains(node.suspensions, s) {
+			return
+		}
+		node.suspensions = slices.DeleteFunc(node.suspensions, func(other *suspension) bool { return other == s })
+		if len(node.suspensions) == 0 {
+			node.suspendedBy.Store(nil)
+			node.log().Info("ID generation resumed")
+		} else {
+			node.suspendedBy.Store(node.suspensions[0])
+		}
+	}
+}
 
+// Returns the error for the oldest reason the node is suspended, or nil if it is not suspended.
+func (node *SnowballNode) suspended() error {
+	if s := node.suspendedBy.Load(); s != nil {
+		return s.err
+	}
+	return nil
+}
+
+// Returns the reason the node itself cannot issue IDs, or nil if it can. Must be called with the node's lock
+// held.
+func (node *SnowballNode) unavailable() error {
+	if node.typed {
+		return ErrPartitioned
+	}
+	return node.suspended()
+}
+
+// Advances the node's sequence and returns the next ID. Must be called with the node's lock held.
+func (node *SnowballNode) nextID() (SnowballID, error) {
+	now, seq, err := node.sequencer.next(node, uint64(MaxSequence))
+	return SnowballID((now << uint64(timestampShift)) | (node.serverId << uint64(serverIdShift)) | seq), err
+}
+
+// The timestamp and sequence of the last ID issued by a node or TypeGenerator.
+type sequencer struct {
+	currTime uint64
+	currSeq  uint64
+}
+
+// Advances to the next timestamp and sequence number, wrapping the sequence after maxSequence. Waits for the
+// next millisecond if the sequence is exhausted, and for the clock to catch up if it is behind the last issued
+// timestamp. Returns ErrTimestampExhausted if the timestamp no longer fits in the node's layout, and
+// ErrStateNotSaved if it is not covered by the node's state file and the file cannot be written. Must be called
+// with the lock guarding the sequencer held.
+func (s *sequencer) next(node *SnowballNode, maxSequence uint64) (timestamp, sequence uint64, err error) {
 	now := time.Since(node.epoch).Milliseconds()
-	if now == int64(node.currTime) {
-		node.currSeq = (node.currSeq + 1) & uint64(MaxSequence)
-		if node.currSeq == 0 {
-			for now <= int64(node.currTime) {
-				now = int64(time.Since(node.epoch).Milliseconds())
-			}
+	if now < int64(s.currTime) {
+		// The clock is behind the last issued timestamp; wait for it to catch up rather than risk reissuing IDs.
+		if node.metrics != nil {
+			node.metrics.clockRollbacks.Inc()
 		}
+		node.log().Warn("clock moved backwards, waiting for it to catch up",
+			slog.Int64("behind_ms", int64(s.currTime)-now),
+		)
+		for now < int64(s.currTime) {
+			now = int64(time.Since(node.epoch).Milliseconds())
+		}
+	}
+
+	if now != int64(s.currTime) {
+		s.currSeq = node.sequenceStart(maxSequence)
+	} else if s.currSeq < maxSequence {
+		s.currSeq++
 	} else {
-		node.currSeq = 0
+		if node.metrics != nil {
+			node.metrics.sequenceExhausted.Inc()
+		}
+		for now <= int64(s.currTime) {
+			now = int64(time.Since(node.epoch).Milliseconds())
+		}
+		s.currSeq = node.sequenceStart(maxSequence)
 	}
 
-	node.currTime = uint64(now)
-	result := SnowballID(
-		(uint64(now) << uint64(timestampShift)) | (node.serverId << uint64(serverIdShift)) | node.currSeq,
-	)
-	return result
+	if uint64(now) > node.layout.MaxTimestamp() {
+		return 0, 0, ErrTimestampExhausted
+	}
+	if node.state != nil {
+		if err := node.state.reserve(node.epoch.UnixMilli() + now); err != nil {
+			return 0, 0, err
+		}
+	}
+	s.currTime = uint64(now)
+	return s.currTime, s.currSeq, nil
+}
+
+// Returns the sequence number of the first ID in a millisecond: 0, or a random number in the lower half of the
+// sequence range if the node was created with WithRandomSequenceStart.
+func (node *SnowballNode) sequenceStart(maxSequence uint64) uint64 {
+	if !node.randomSequence {
+		return 0
+	}
+	return rand.Uint64N(maxSequence/2 + 1)
+}
+
+// Records a completed call that generated count IDs and started at the given time.
+func (node *SnowballNode) observe(start time.Time, count int) {
+	node.metrics.generationLatency.Observe(time.Since(start).Seconds())
+	node.metrics.idsIssued.Add(float64(count))
+}
+
+// Returns the epoch that timestamps in IDs generated by the node are relative to.
+func (node *SnowballNode) Epoch() time.Time {
+	return node.epoch.Round(0)
+}
+
+// Returns the namespace set with WithNamespace, or "" if none was set.
+func (node *SnowballNode) Namespace() string {
+	return node.namespace
+}
+
+// Returns the server ID embedded in IDs generated by the node.
+func (node *SnowballNode) ServerId() uint64 {
+	return node.serverId
+}
+
+// Returns the name of the source the node's server ID was taken from, e.g. "env", "ip" or "interface", or
+// "default" if none was configured.
+func (node *SnowballNode) ServerIdSource() string {
+	return node.serverIdSource
+}
+
+// Reports whether the node is ready to issue IDs. Returns ErrServerIdUnassigned if the node fell back to the
+// default server ID because none was configured, an error wrapping ErrSuspended if the node is suspended, or
+// ErrClockBehind if the clock is currently behind the timestamp of the last issued ID (in which case generation
+// would wait for it to catch up).
+func (node *SnowballNode) Ready() error {
+	if !node.assigned {
+		return ErrServerIdUnassigned
+	}
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+
+	if err := node.suspended(); err != nil {
+		return err
+	}
+	now := time.Since(node.epoch).Milliseconds()
+	if now > int64(node.layout.MaxTimestamp()) {
+		return ErrTimestampExhausted
+	}
+	if now < int64(node.currTime) {
+		return ErrClockBehind
+	}
+	return nil
 }
@@ -1,6 +1,11 @@
 package snowball
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestInitNode(t *testing.T) {
 	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
@@ -18,6 +23,50 @@ func TestInitNode(t *testing.T) {
 	}
 }
 
+func TestInitNodeStrictEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		epoch   string
+		nodeId  string
+		want    uint64
+		wantErr string
+		lenient uint64
+	}{
+		{name: "Valid", epoch: "1704121810000", nodeId: "32", want: 32, lenient: 32},
+		{name: "Missing epoch", nodeId: "32", want: 32, lenient: 32},
+		{name: "Malformed epoch", epoch: "yesterday", nodeId: "32", wantErr: "SNOWBALL_EPOCH_MS", lenient: 32},
+		{name: "Missing server ID", epoch: "1704121810000", wantErr: "SNOWBALL_NODE_ID is not set"},
+		{name: "Malformed server ID", epoch: "1704121810000", nodeId: "3two", wantErr: "SNOWBALL_NODE_ID is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SNOWBALL_EPOCH_MS", tt.epoch)
+			t.Setenv("SNOWBALL_NODE_ID", tt.nodeId)
+
+			node, err := InitNode(false, WithStrictEnv())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("InitNode(WithStrictEnv()) error: %v, want error mentioning %s", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("Error occurred running InitNode(WithStrictEnv()): %s", err)
+			} else if node.ServerId() != tt.want {
+				t.Errorf("InitNode(WithStrictEnv()) server ID = %d, want: %d", node.ServerId(), tt.want)
+			}
+
+			// Without strict mode, the node falls back to the defaults.
+			node, err = InitNode(false)
+			if err != nil {
+				t.Fatalf("Error occurred running InitNode: %s", err)
+			}
+			if node.ServerId() != tt.lenient {
+				t.Errorf("InitNode() server ID = %d, want: %d", node.ServerId(), tt.lenient)
+			}
+		})
+	}
+}
+
 func TestGenerateDuplicateIDs(t *testing.T) {
 	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
 	t.Setenv("SNOWBALL_NODE_ID", "32")
@@ -45,3 +94,134 @@ func BenchmarkGenerateID(b *testing.B) {
 		_ = node.GenerateID()
 	}
 }
+
+func TestGenerateIDs(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	if ids := node.GenerateIDs(0); len(ids) != 0 {
+		t.Errorf("GenerateIDs(0) returned %d IDs, want: 0", len(ids))
+	}
+
+	last := node.GenerateID()
+	for i := 0; i < 100; i++ {
+		ids := node.GenerateIDs(5000)
+		if len(ids) != 5000 {
+			t.Fatalf("GenerateIDs(5000) returned %d IDs, want: 5000", len(ids))
+		}
+		for _, id := range ids {
+			if id <= last {
+				t.Fatalf("IDs %d and %d are not increasing", last, id)
+			}
+			last = id
+		}
+	}
+}
+
+func BenchmarkGenerateIDs(b *testing.B) {
+	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	b.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		_ = node.GenerateIDs(100)
+	}
+}
+
+func TestRandomSequenceStart(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	// With one ID per millisecond, the sequence would always be 0 without a random start.
+	node, _ := InitNode(false, WithRandomSequenceStart())
+	var shards [4]int
+	for i := 0; i < 200; i++ {
+		_, _, sequence := DefaultLayout.Decompose(node.GenerateID())
+		if sequence > uint64(MaxSequence)/2 {
+			t.Fatalf("Sequence %d of the first ID in a millisecond is not in the lower half of its range", sequence)
+		}
+		shards[sequence%4]++
+		time.Sleep(time.Millisecond)
+	}
+	for shard, count := range shards {
+		if count < 20 {
+			t.Errorf("%d of 200 IDs fell in shard %d of 4, want about 50", count, shard)
+		}
+	}
+
+	// Exhausting the sequence still waits for the next millisecond rather than wrapping around.
+	last := node.GenerateID()
+	for _, id := range node.GenerateIDs(20000) {
+		if id <= last {
+			t.Fatalf("IDs %d and %d are not increasing", last, id)
+		}
+		last = id
+	}
+}
+
+func TestReady(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() error: %v, want: nil", err)
+	}
+
+	node.currTime = uint64(time.Since(node.epoch).Milliseconds()) + 60000
+	if err := node.Ready(); !errors.Is(err, ErrClockBehind) {
+		t.Errorf("Ready() error: %v, want: %v", err, ErrClockBehind)
+	}
+
+	t.Setenv("SNOWBALL_NODE_ID", "")
+	node, _ = InitNode(false)
+	if err := node.Ready(); !errors.Is(err, ErrServerIdUnassigned) {
+		t.Errorf("Ready() error: %v, want: %v", err, ErrServerIdUnassigned)
+	}
+}
+
+func TestSuspend(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	reason := errors.New("lease lost")
+	resume := node.Suspend(reason)
+
+	if _, err := node.NextID(); !errors.Is(err, ErrSuspended) || !errors.Is(err, reason) {
+		t.Errorf("NextID() error: %v, want: %v and %v", err, ErrSuspended, reason)
+	}
+	if _, err := node.NextIDs(10); !errors.Is(err, ErrSuspended) {
+		t.Errorf("NextIDs() error: %v, want: %v", err, ErrSuspended)
+	}
+	if id := node.GenerateID(); id != 0 {
+		t.Errorf("GenerateID() = %v while suspended, want: 0", id)
+	}
+	if ids := node.GenerateIDs(10); len(ids) != 0 {
+		t.Errorf("GenerateIDs() returned %d IDs while suspended, want: 0", len(ids))
+	}
+	if err := node.Ready(); !errors.Is(err, ErrSuspended) {
+		t.Errorf("Ready() error: %v, want: %v", err, ErrSuspended)
+	}
+
+	// The node stays suspended until every reason is resolved.
+	other := errors.New("collision")
+	resumeOther := node.Suspend(other)
+	resume()
+	resume()
+	if _, err := node.NextID(); !errors.Is(err, other) {
+		t.Errorf("NextID() error: %v, want: %v", err, other)
+	}
+
+	resumeOther()
+	if _, err := node.NextID(); err != nil {
+		t.Errorf("NextID() error after resuming: %v", err)
+	}
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() error after resuming: %v, want: nil", err)
+	}
+}
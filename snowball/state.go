@@ -0,0 +1,125 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrStateNotSaved = errors.New("node not ready: could not persist the last issued timestamp")
+
+// How far ahead of the current time timestamps are reserved in a state file, so the file is written at most once
+// per interval rather than for every millisecond. A restarted node waits out at most this long.
+const stateReservation = time.Second
+
+// Timestamps reserved in a state file, shared by all nodes created with the same WithStateFile option.
+type persistedState struct {
+	path string
+
+	mutex sync.Mutex
+	// Whether the file has been read, and the time it held, in milliseconds since the Unix epoch.
+	loaded   bool
+	restored int64
+	// Time up to which IDs may be issued without writing the file again, in milliseconds since the Unix epoch.
+	// Only written with the mutex held.
+	reserved atomic.Int64
+}
+
+// Persists the time of the last issued ID in the file at path and resumes after it when the node is created
+// again, so IDs keep increasing across restarts even if the clock moved backwards while the node was down. Until
+// the clock passes the persisted time, the node waits before issuing IDs and Ready reports ErrClockBehind.
+//
+// The node reserves timestamps a second ahead, writing the file (and waiting for it to reach the disk) at most
+// once a second, so a restarted node may wait for up to a second. If the file cannot be written, NextID and
+// NextIDs return ErrStateNotSaved. Nodes created with the same Option, such as the shards of a ShardedNode,
+// share the file; other nodes must not use it.
+func WithStateFile(path string) Option {
+	state := &persistedState{path: path}
+	return func(node *SnowballNode) {
+		node.state = state
+	}
+}
+
+// Resumes the node's sequence after the time persisted in its state file, if any. Must be called once the
+// node's epoch is set.
+func (node *SnowballNode) restoreState() error {
+	restored, err := node.state.load()
+	if err != nil {
+		return err
+	}
+	if timestamp := restored - node.epoch.UnixMilli(); timestamp > 0 {
+		// IDs up to the end of the restored millisecond may have been issued, so start in the next one.
+		node.currTime = uint64(timestamp)
+		node.currSeq = uint64(MaxSequence)
+	}
+	return nil
+}
+
+// Reads the state file the first time it is called, returning the persisted time in milliseconds since the Unix
+// epoch, or 0 if the file does not exist yet.
+func (s *persistedState) load() (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.loaded {
+		return s.restored, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("reading state file failed: %w", err)
+	} else if err == nil {
+		if s.restored, err = strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err != nil {
+			return 0, fmt.Errorf("reading state file failed: %s: invalid timestamp %q", s.path, data)
+		}
+	}
+	s.loaded = true
+	s.reserved.Store(s.restored)
+	return s.restored, nil
+}
+
+// Makes sure IDs at the given time, in milliseconds since the Unix epoch, are covered by the state file,
+// reserving the following stateReservation if they are not.
+func (s *persistedState) reserve(unixMs int64) error {
+	if unixMs <= s.reserved.Load() {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if unixMs <= s.reserved.Load() {
+		return nil
+	}
+
+	reserved := unixMs + stateReservation.Milliseconds()
+	if err := writeState(s.path, reserved); err != nil {
+		return fmt.Errorf("%w: %w", ErrStateNotSaved, err)
+	}
+	s.reserved.Store(reserved)
+	return nil
+}
+
+// Replaces the state file with one holding the given time, so a crash leaves either the old or the new file.
+func writeState(path string, unixMs int64) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(strconv.FormatInt(unixMs, 10) + "\n")
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,91 @@
+package snowball
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStateFile(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+	path := filepath.Join(t.TempDir(), "snowball.state")
+
+	node, err := InitNode(false, WithStateFile(path))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	last := node.GenerateID()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error occurred reading the state file: %s", err)
+	}
+	reserved, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if issued := Decompose(last, node.Epoch()).Time.UnixMilli(); err != nil || reserved < issued {
+		t.Errorf("State file holds %q, want a time at or after %d", data, issued)
+	}
+
+	// A restarted node resumes after the reserved time, even though the clock has not reached it yet.
+	node, err = InitNode(false, WithStateFile(path))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if err := node.Ready(); !errors.Is(err, ErrClockBehind) {
+		t.Errorf("Ready() error after restart: %v, want: %v", err, ErrClockBehind)
+	}
+	id := node.GenerateID()
+	if id <= last || Decompose(id, node.Epoch()).Time.UnixMilli() <= reserved {
+		t.Errorf("ID %d after restart is not after the reserved time %d", id, reserved)
+	}
+
+	// Nodes created with the same option share the state, as the shards of a ShardedNode do.
+	shared := WithStateFile(filepath.Join(t.TempDir(), "snowball.state"))
+	first, _ := InitNode(false, shared)
+	second, _ := InitNode(false, shared, WithServerIDProvider(StaticServerID(33)))
+	first.GenerateID()
+	if err := second.Ready(); err != nil {
+		t.Errorf("Ready() error of a node sharing the state: %v, want: nil", err)
+	}
+}
+
+func TestStateFileErrors(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+	dir := t.TempDir()
+
+	malformed := filepath.Join(dir, "malformed.state")
+	if err := os.WriteFile(malformed, []byte("yesterday\n"), 0o644); err != nil {
+		t.Fatalf("Error occurred writing the state file: %s", err)
+	}
+	if _, err := InitNode(false, WithStateFile(malformed)); err == nil {
+		t.Errorf("No error occurred running InitNode with a malformed state file")
+	}
+
+	node, err := InitNode(false, WithStateFile(filepath.Join(dir, "missing", "snowball.state")))
+	if err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if _, err := node.NextID(); !errors.Is(err, ErrStateNotSaved) {
+		t.Errorf("NextID() error: %v, want: %v", err, ErrStateNotSaved)
+	}
+
+	// A state file from before the epoch does not hold the node back.
+	stale := filepath.Join(dir, "stale.state")
+	if err := os.WriteFile(stale, []byte("1000\n"), 0o644); err != nil {
+		t.Fatalf("Error occurred writing the state file: %s", err)
+	}
+	start := time.Now()
+	if node, err = InitNode(false, WithStateFile(stale)); err != nil {
+		t.Fatalf("Error occurred running InitNode: %s", err)
+	}
+	if err := node.Ready(); err != nil {
+		t.Errorf("Ready() error: %v, want: nil", err)
+	}
+	if id := node.GenerateID(); Decompose(id, node.Epoch()).Time.Before(start.Truncate(time.Millisecond)) {
+		t.Errorf("ID %d is from before the node was created", id)
+	}
+}
@@ -0,0 +1,128 @@
+package snowball
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Generates IDs for a single entity type on behalf of a node. Each TypeGenerator owns its own lock and sequence,
+// so generators for different types never contend with each other, and embeds its type in every ID it issues so
+// the type can be recovered with the layout's DecomposeTyped.
+//
+// IDs use TypedLayout(typeLen): the node's timestamp and server ID, followed by the type and a sequence that is
+// typeLen bits shorter than the node's. For nodes created with WithSignedSafe, the timestamp section is that of
+// SignedLayout instead; see Layout. Generators are created with the node's TypeGenerators method.
+type TypeGenerator struct {
+	node   *SnowballNode
+	typ    uint64
+	layout Layout
+
+	mutex sync.Mutex
+	sequencer
+}
+
+// Partitions the node's sequence section into 2^typeLen sub-generators, one for each type. typeLen must be at
+// least 1 and less than SequenceLen. The returned slice is indexed by type.
+//
+// Once partitioned, the node's own NextID and NextIDs return ErrPartitioned, since IDs from them could collide
+// with IDs from the generators. The node can only be partitioned once. Suspending the node suspends all of its
+// generators.
+func (node *SnowballNode) TypeGenerators(typeLen uint8) ([]*TypeGenerator, error) {
+	if typeLen == 0 || typeLen >= SequenceLen {
+		return nil, fmt.Errorf("partition failed: type length must be between 1 and %d, got %d", SequenceLen-1, typeLen)
+	}
+
+	node.mutex.Lock()
+	defer node.mutex.Unlock()
+	if node.typed {
+		return nil, errors.New("partition failed: node is already partitioned")
+	}
+
+	layout := node.layout.typed(typeLen)
+	result := make([]*TypeGenerator, layout.MaxType()+1)
+	for i := range result {
+		// Start after the last ID the node itself issued, so no generator can reissue it.
+		result[i] = &TypeGenerator{node: node, typ: uint64(i), layout: layout, sequencer: sequencer{
+			currTime: node.currTime,
+			currSeq:  layout.MaxSequence(),
+		}}
+	}
+	node.typed = true
+	return result, nil
+}
+
+// Returns the type embedded in IDs issued by the generator.
+func (g *TypeGenerator) Type() uint64 {
+	return g.typ
+}
+
+// Returns the layout of IDs issued by the generator, for decoding them with DecomposeTyped.
+func (g *TypeGenerator) Layout() Layout {
+	return g.layout
+}
+
+// Creates and returns a new, unique Snowball ID of the generator's type. Returns 0 if the node is suspended;
+// use NextID to tell this apart from a valid ID.
+func (g *TypeGenerator) GenerateID() SnowballID {
+	id, _ := g.NextID()
+	return id
+}
+
+// Creates and returns a new, unique Snowball ID of the generator's type, or an error wrapping ErrSuspended if
+// the node is suspended.
+func (g *TypeGenerator) NextID() (SnowballID, error) {
+	start := time.Now()
+	g.mutex.Lock()
+	if err := g.node.suspended(); err != nil {
+		g.mutex.Unlock()
+		return 0, err
+	}
+	result, err := g.nextID()
+	g.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	if g.node.metrics != nil {
+		g.node.observe(start, 1)
+	}
+	return result, nil
+}
+
+// Creates and returns count new, unique Snowball IDs of the generator's type in increasing order, or an error
+// wrapping ErrSuspended if the node is suspended. Returns an empty slice if count is not positive.
+func (g *TypeGenerator) NextIDs(count int) ([]SnowballID, error) {
+	if count <= 0 {
+		return []SnowballID{}, nil
+	}
+
+	start := time.Now()
+	result := make([]SnowballID, count)
+	g.mutex.Lock()
+	if err := g.node.suspended(); err != nil {
+		g.mutex.Unlock()
+		return nil, err
+	}
+	for i := range result {
+		var err error
+		if result[i], err = g.nextID(); err != nil {
+			g.mutex.Unlock()
+			return nil, err
+		}
+	}
+	g.mutex.Unlock()
+
+	if g.node.metrics != nil {
+		g.node.observe(start, count)
+	}
+	return result, nil
+}
+
+// Advances the generator's sequence and returns the next ID. Must be called with the generator's lock held.
+func (g *TypeGenerator) nextID() (SnowballID, error) {
+	now, seq, err := g.sequencer.next(g.node, g.layout.MaxSequence())
+	return SnowballID((now << uint64(timestampShift)) | (g.node.serverId << uint64(serverIdShift)) |
+		(g.typ << g.layout.SequenceLen) | seq), err
+}
@@ -0,0 +1,89 @@
+package snowball
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestTypeGenerators(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	last := node.GenerateID()
+	for _, typeLen := range []uint8{0, SequenceLen} {
+		if _, err := node.TypeGenerators(typeLen); err == nil {
+			t.Errorf("No error occurred running TypeGenerators(%d)", typeLen)
+		}
+	}
+
+	generators, err := node.TypeGenerators(2)
+	if err != nil {
+		t.Fatalf("Error occurred running TypeGenerators: %s", err)
+	}
+	if len(generators) != 4 {
+		t.Fatalf("TypeGenerators(2) returned %d generators, want: 4", len(generators))
+	}
+	if _, err := node.TypeGenerators(2); err == nil {
+		t.Errorf("No error occurred partitioning a node twice")
+	}
+	if _, err := node.NextID(); !errors.Is(err, ErrPartitioned) {
+		t.Errorf("NextID() error on a partitioned node: %v, want: %v", err, ErrPartitioned)
+	}
+	if _, err := node.NextIDs(10); !errors.Is(err, ErrPartitioned) {
+		t.Errorf("NextIDs() error on a partitioned node: %v, want: %v", err, ErrPartitioned)
+	}
+	if id := generators[0].GenerateID(); id <= last {
+		t.Errorf("TypeGenerator ID %d is not greater than the node's last ID %d", id, last)
+	}
+
+	resume := node.Suspend(nil)
+	if _, err := generators[1].NextID(); !errors.Is(err, ErrSuspended) {
+		t.Errorf("TypeGenerator NextID() error while suspended: %v, want: %v", err, ErrSuspended)
+	}
+	resume()
+}
+
+func TestTypeGeneratorsConcurrent(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	generators, err := node.TypeGenerators(3)
+	if err != nil {
+		t.Fatalf("Error occurred running TypeGenerators: %s", err)
+	}
+
+	results := make([][]SnowballID, len(generators))
+	var wg sync.WaitGroup
+	for i, g := range generators {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				ids, _ := g.NextIDs(1000)
+				results[i] = append(results[i], ids...)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[SnowballID]bool)
+	for i, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("ID %d was issued more than once", id)
+			}
+			seen[id] = true
+
+			_, serverId, typ, _ := generators[i].Layout().DecomposeTyped(id)
+			if typ != uint64(i) || serverId != 32 {
+				t.Fatalf("DecomposeTyped(%d) type %d, server ID %d, want: %d, 32", id, typ, serverId, i)
+			}
+		}
+	}
+	if len(seen) != 8*20*1000 {
+		t.Errorf("Generated %d unique IDs, want: %d", len(seen), 8*20*1000)
+	}
+}
@@ -0,0 +1,111 @@
+package snowball
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// Basic type to represent an RFC 9562 UUID, e.g., for storing Snowball IDs in UUID-typed columns.
+//
+// Snowball IDs are embedded in version 8 ("custom") UUIDs. The 64 bits of the ID are laid out from the
+// most significant bit downward, skipping over the version and variant bits:
+//   - custom_a (48 bits): the upper 48 bits of the ID
+//   - ver (4 bits): 0b1000
+//   - custom_b (12 bits): the next 12 bits of the ID
+//   - var (2 bits): 0b10
+//   - custom_c (62 bits): the lowest 4 bits of the ID, followed by 58 zero bits
+//
+// Because the ID occupies the most significant bits, UUIDs sort in the same order as the Snowball IDs
+// they were created from, whether compared bytewise or as canonical strings.
+type UUID [16]byte
+
+const (
+	uuidVersion byte = 0x80
+	uuidVariant byte = 0x80
+)
+
+// Formats the Snowball ID into an RFC 9562 version 8 UUID.
+func (id SnowballID) ToUUID() UUID {
+	var u UUID
+	v := uint64(id)
+
+	u[0] = byte(v >> 56)
+	u[1] = byte(v >> 48)
+	u[2] = byte(v >> 40)
+	u[3] = byte(v >> 32)
+	u[4] = byte(v >> 24)
+	u[5] = byte(v >> 16)
+	u[6] = uuidVersion | byte(v>>12)&0x0f
+	u[7] = byte(v >> 4)
+	u[8] = uuidVariant | byte(v&0x0f)<<2
+	return u
+}
+
+// Converts an RFC 9562 version 8 UUID back into a Snowball ID. Fails if the UUID was not created by ToUUID.
+func FromUUID(u UUID) (SnowballID, error) {
+	if u[6]&0xf0 != uuidVersion {
+		return 0, errors.New("decode failed: UUID is not version 8")
+	}
+	if u[8]&0xc0 != uuidVariant {
+		return 0, errors.New("decode failed: UUID does not use the RFC 9562 variant")
+	}
+	if u[8]&0x03 != 0 || u[9]|u[10]|u[11]|u[12]|u[13]|u[14]|u[15] != 0 {
+		return 0, errors.New("decode failed: UUID does not contain a Snowball ID")
+	}
+
+	v := uint64(u[0])<<56 | uint64(u[1])<<48 | uint64(u[2])<<40 | uint64(u[3])<<32 |
+		uint64(u[4])<<24 | uint64(u[5])<<16 | uint64(u[6]&0x0f)<<12 | uint64(u[7])<<4 |
+		uint64(u[8]>>2)&0x0f
+	return SnowballID(v), nil
+}
+
+// Formats the UUID in canonical 8-4-4-4-12 form, e.g., "0045c4d6-8dc1-8000-8000-000000000000".
+func (u UUID) String() string {
+	b := make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b)
+}
+
+// Converts a UUID string in canonical 8-4-4-4-12 form into a UUID. Both upper and lower case hex digits
+// are accepted.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errors.New("decode failed: UUID must be in 8-4-4-4-12 form")
+	}
+
+	digits := make([]byte, 0, 32)
+	digits = append(digits, s[0:8]...)
+	digits = append(digits, s[9:13]...)
+	digits = append(digits, s[14:18]...)
+	digits = append(digits, s[19:23]...)
+	digits = append(digits, s[24:]...)
+	if _, err := hex.Decode(u[:], digits); err != nil {
+		return UUID{}, errors.New("decode failed: invalid hex digits in UUID")
+	}
+
+	return u, nil
+}
+
+// Formats the Snowball ID into a version 8 UUID string in canonical 8-4-4-4-12 form.
+func (id SnowballID) ToUUIDString() string {
+	return id.ToUUID().String()
+}
+
+// Converts a version 8 UUID string in canonical 8-4-4-4-12 form into a Snowball ID.
+func FromUUIDString(sid string) (SnowballID, error) {
+	u, err := ParseUUID(sid)
+	if err != nil {
+		return 0, err
+	}
+
+	return FromUUID(u)
+}
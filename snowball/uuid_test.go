@@ -0,0 +1,159 @@
+package snowball
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUUIDEncode(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  SnowballID
+		want string
+	}{
+		{
+			name: "Test 1",
+			arg:  19638199173316608,
+			want: "0045c4d6-8dc1-8000-8000-000000000000",
+		},
+		{
+			name: "Test 2",
+			arg:  19638200368693248,
+			want: "0045c4d6-d501-8000-8000-000000000000",
+		},
+		{
+			name: "All bits set",
+			arg:  0xffffffffffffffff,
+			want: "ffffffff-ffff-8fff-bc00-000000000000",
+		},
+		{
+			name: "Low bits set",
+			arg:  0x123456789abcdef7,
+			want: "12345678-9abc-8def-9c00-000000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.arg.ToUUIDString()
+			if encoded != tt.want {
+				t.Errorf("ToUUIDString() encoded: %v, want: %v", encoded, tt.want)
+			}
+		})
+	}
+}
+
+func TestUUIDDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		want    SnowballID
+		wantErr bool
+	}{
+		{
+			name:    "Test 1",
+			arg:     "0045c4d6-8dc1-8000-8000-000000000000",
+			want:    19638199173316608,
+			wantErr: false,
+		},
+		{
+			name:    "Upper case",
+			arg:     "12345678-9ABC-8DEF-9C00-000000000000",
+			want:    0x123456789abcdef7,
+			wantErr: false,
+		},
+		{
+			name:    "Wrong version",
+			arg:     "0045c4d6-8dc1-4000-8000-000000000000",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Wrong variant",
+			arg:     "0045c4d6-8dc1-8000-c000-000000000000",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Non-zero padding",
+			arg:     "0045c4d6-8dc1-8000-8000-000000000001",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Missing hyphens",
+			arg:     "0045c4d68dc1800080000000000000000000",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid hex digits",
+			arg:     "0045c4d6-8dc1-8000-8000-00000000000g",
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := FromUUIDString(tt.arg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FromUUIDString() error: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if decoded != tt.want {
+				t.Errorf("FromUUIDString() decoded: %v, want: %v", decoded, tt.want)
+			}
+		})
+	}
+}
+
+func TestUUIDOrdering(t *testing.T) {
+	t.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	t.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	prevId := node.GenerateID()
+	prev := prevId.ToUUID()
+	for i := 0; i < 100000; i++ {
+		id := node.GenerateID()
+		u := id.ToUUID()
+		if bytes.Compare(prev[:], u[:]) >= 0 || prev.String() >= u.String() {
+			t.Fatalf("UUIDs for IDs %d and %d are not ordered: %s, %s", prevId, id, prev, u)
+		}
+
+		decoded, err := FromUUID(u)
+		if err != nil || decoded != id {
+			t.Fatalf("FromUUID() decoded: %v, want: %v (error: %v)", decoded, id, err)
+		}
+		prevId, prev = id, u
+	}
+}
+
+func BenchmarkToUUID(b *testing.B) {
+	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	b.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	id := node.GenerateID()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		id.ToUUIDString()
+	}
+}
+
+func BenchmarkFromUUID(b *testing.B) {
+	b.Setenv("SNOWBALL_EPOCH_MS", "1704121810000")
+	b.Setenv("SNOWBALL_NODE_ID", "32")
+
+	node, _ := InitNode(false)
+	id := node.GenerateID()
+	sid := id.ToUUIDString()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		FromUUIDString(sid)
+	}
+}
@@ -0,0 +1,4 @@
+// Package snowballpb contains the Protocol Buffers messages and gRPC service definitions for Snowball.
+package snowballpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative snowball.proto
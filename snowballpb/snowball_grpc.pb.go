@@ -0,0 +1,264 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v4.25.3
+// source: snowball.proto
+
+package snowballpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	IDGenerator_Generate_FullMethodName       = "/snowball.v1.IDGenerator/Generate"
+	IDGenerator_GenerateBatch_FullMethodName  = "/snowball.v1.IDGenerator/GenerateBatch"
+	IDGenerator_Parse_FullMethodName          = "/snowball.v1.IDGenerator/Parse"
+	IDGenerator_GenerateStream_FullMethodName = "/snowball.v1.IDGenerator/GenerateStream"
+)
+
+// IDGeneratorClient is the client API for IDGenerator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Generates and parses Snowball IDs.
+type IDGeneratorClient interface {
+	// Generates a single Snowball ID.
+	Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*SnowballID, error)
+	// Generates several Snowball IDs in one round trip.
+	GenerateBatch(ctx context.Context, in *GenerateBatchRequest, opts ...grpc.CallOption) (*GenerateBatchResponse, error)
+	// Decomposes an existing Snowball ID into its sections.
+	Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*SnowballID, error)
+	// Streams Snowball IDs as they are generated.
+	GenerateStream(ctx context.Context, in *GenerateStreamRequest, opts ...grpc.CallOption) (IDGenerator_GenerateStreamClient, error)
+}
+
+type iDGeneratorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIDGeneratorClient(cc grpc.ClientConnInterface) IDGeneratorClient {
+	return &iDGeneratorClient{cc}
+}
+
+func (c *iDGeneratorClient) Generate(ctx context.Context, in *GenerateRequest, opts ...grpc.CallOption) (*SnowballID, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnowballID)
+	err := c.cc.Invoke(ctx, IDGenerator_Generate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iDGeneratorClient) GenerateBatch(ctx context.Context, in *GenerateBatchRequest, opts ...grpc.CallOption) (*GenerateBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GenerateBatchResponse)
+	err := c.cc.Invoke(ctx, IDGenerator_GenerateBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iDGeneratorClient) Parse(ctx context.Context, in *ParseRequest, opts ...grpc.CallOption) (*SnowballID, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SnowballID)
+	err := c.cc.Invoke(ctx, IDGenerator_Parse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iDGeneratorClient) GenerateStream(ctx context.Context, in *GenerateStreamRequest, opts ...grpc.CallOption) (IDGenerator_GenerateStreamClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IDGenerator_ServiceDesc.Streams[0], IDGenerator_GenerateStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &iDGeneratorGenerateStreamClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type IDGenerator_GenerateStreamClient interface {
+	Recv() (*SnowballID, error)
+	grpc.ClientStream
+}
+
+type iDGeneratorGenerateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *iDGeneratorGenerateStreamClient) Recv() (*SnowballID, error) {
+	m := new(SnowballID)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// IDGeneratorServer is the server API for IDGenerator service.
+// All implementations must embed UnimplementedIDGeneratorServer
+// for forward compatibility
+//
+// Generates and parses Snowball IDs.
+type IDGeneratorServer interface {
+	// Generates a single Snowball ID.
+	Generate(context.Context, *GenerateRequest) (*SnowballID, error)
+	// Generates several Snowball IDs in one round trip.
+	GenerateBatch(context.Context, *GenerateBatchRequest) (*GenerateBatchResponse, error)
+	// Decomposes an existing Snowball ID into its sections.
+	Parse(context.Context, *ParseRequest) (*SnowballID, error)
+	// Streams Snowball IDs as they are generated.
+	GenerateStream(*GenerateStreamRequest, IDGenerator_GenerateStreamServer) error
+	mustEmbedUnimplementedIDGeneratorServer()
+}
+
+// UnimplementedIDGeneratorServer must be embedded to have forward compatible implementations.
+type UnimplementedIDGeneratorServer struct {
+}
+
+func (UnimplementedIDGeneratorServer) Generate(context.Context, *GenerateRequest) (*SnowballID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Generate not implemented")
+}
+func (UnimplementedIDGeneratorServer) GenerateBatch(context.Context, *GenerateBatchRequest) (*GenerateBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateBatch not implemented")
+}
+func (UnimplementedIDGeneratorServer) Parse(context.Context, *ParseRequest) (*SnowballID, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Parse not implemented")
+}
+func (UnimplementedIDGeneratorServer) GenerateStream(*GenerateStreamRequest, IDGenerator_GenerateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GenerateStream not implemented")
+}
+func (UnimplementedIDGeneratorServer) mustEmbedUnimplementedIDGeneratorServer() {}
+
+// UnsafeIDGeneratorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IDGeneratorServer will
+// result in compilation errors.
+type UnsafeIDGeneratorServer interface {
+	mustEmbedUnimplementedIDGeneratorServer()
+}
+
+func RegisterIDGeneratorServer(s grpc.ServiceRegistrar, srv IDGeneratorServer) {
+	s.RegisterService(&IDGenerator_ServiceDesc, srv)
+}
+
+func _IDGenerator_Generate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).Generate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IDGenerator_Generate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).Generate(ctx, req.(*GenerateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IDGenerator_GenerateBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).GenerateBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IDGenerator_GenerateBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).GenerateBatch(ctx, req.(*GenerateBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IDGenerator_Parse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).Parse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IDGenerator_Parse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).Parse(ctx, req.(*ParseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IDGenerator_GenerateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IDGeneratorServer).GenerateStream(m, &iDGeneratorGenerateStreamServer{ServerStream: stream})
+}
+
+type IDGenerator_GenerateStreamServer interface {
+	Send(*SnowballID) error
+	grpc.ServerStream
+}
+
+type iDGeneratorGenerateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *iDGeneratorGenerateStreamServer) Send(m *SnowballID) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// IDGenerator_ServiceDesc is the grpc.ServiceDesc for IDGenerator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IDGenerator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "snowball.v1.IDGenerator",
+	HandlerType: (*IDGeneratorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Generate",
+			Handler:    _IDGenerator_Generate_Handler,
+		},
+		{
+			MethodName: "GenerateBatch",
+			Handler:    _IDGenerator_GenerateBatch_Handler,
+		},
+		{
+			MethodName: "Parse",
+			Handler:    _IDGenerator_Parse_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GenerateStream",
+			Handler:       _IDGenerator_GenerateStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "snowball.proto",
+}